@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// cacheTempPattern names the files writeCacheFile writes before renaming
+// them into place. One left behind means a write was interrupted.
+const cacheTempPattern = ".tmp-*"
+
+// uniCacheDir is the `uni` directory of os.UserCacheDir, which holds every
+// cache file uni keeps between runs.
+func uniCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "uni"), nil
+}
+
+// writeCacheFile replaces the cache file name with data atomically: the data
+// goes to a temp file in the same directory, which is renamed over the old
+// file once it is complete. A crash or Ctrl-C part way through leaves the
+// old file intact and at worst a stray temp file for `uni cache verify`.
+func writeCacheFile(name string, data []byte) error {
+	dir, err := uniCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, name+cacheTempPattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// readCacheJSON decodes the cache file name into v. It reports false when
+// the file is missing or unreadable; a file that isn't valid JSON is deleted
+// so the next write starts clean.
+func readCacheJSON(name string, v any) bool {
+	dir, err := uniCacheDir()
+	if err != nil {
+		return false
+	}
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		logVerbose("discarding corrupt cache file %s: %v", path, err)
+		os.Remove(path)
+		return false
+	}
+	return true
+}
+
+// handleCacheVerify implements `uni cache verify`. It removes temp files
+// left by interrupted writes and cache files that don't decode, and prunes
+// exec cache entries too incomplete to use.
+func handleCacheVerify() {
+	dir, err := uniCacheDir()
+	if err != nil {
+		exitWithError(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		color.Green("✅ The cache at %s is empty.", dir)
+		return
+	}
+	if err != nil {
+		exitWithError(fmt.Errorf("could not read the cache at %s: %w", dir, err))
+	}
+
+	var checked, removed, pruned int
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() {
+			continue
+		}
+		if matched, _ := filepath.Match("*"+cacheTempPattern, name); matched {
+			if os.Remove(path) == nil {
+				color.Yellow("Removed %s, left by an interrupted write.", name)
+				removed++
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		checked++
+		data, err := os.ReadFile(path)
+		if err != nil {
+			color.Red("Could not read %s: %v", name, err)
+			continue
+		}
+		if !json.Valid(data) {
+			if os.Remove(path) == nil {
+				color.Yellow("Removed %s, which isn't valid JSON.", name)
+				removed++
+			}
+			continue
+		}
+		if name == execCacheFile {
+			pruned += pruneExecCache()
+		}
+	}
+	if removed == 0 && pruned == 0 {
+		color.Green("✅ Checked %d cache files in %s; all are intact.", checked, dir)
+		return
+	}
+	color.Green("✅ Checked %d cache files in %s: removed %d files and pruned %d entries.", checked, dir, removed, pruned)
+}
+
+// pruneExecCache drops runner cache entries that can't be used, returning
+// how many were dropped.
+func pruneExecCache() int {
+	cache := loadExecCache()
+	pruned := 0
+	for key, entry := range cache {
+		if _, ok := supportedManagers[entry.Manager]; ok && len(entry.Runner) > 0 && entry.File != "" {
+			continue
+		}
+		color.Yellow("Pruned the runner cache entry for %s.", strings.ReplaceAll(key, "|", " "))
+		delete(cache, key)
+		pruned++
+	}
+	if pruned > 0 {
+		if err := saveExecCache(cache); err != nil {
+			color.Red("Could not rewrite %s: %v", execCacheFile, err)
+		}
+	}
+	return pruned
+}
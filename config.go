@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// uniConfig is the parsed contents of a .unirc file.
+//
+// The file uses a small TOML subset of `key = value` lines, where a value is
+// a quoted string or an array of quoted strings:
+//
+//	manager = "pnpm"
+//	prefer = ["bun", "pnpm", "npm"]
+//
+// A file holding nothing but a manager key (the format written by older
+// versions of `uni init`) is still accepted.
+type uniConfig struct {
+	Manager string
+	Prefer  []string
+	// ManagerFile and PreferFile name the file each setting was read from.
+	ManagerFile string
+	PreferFile  string
+}
+
+// configValue is one raw `key = value` assignment and the line it came from.
+type configValue struct {
+	Line   int
+	Str    string
+	List   []string
+	IsList bool
+}
+
+// loadConfig reads and parses the config file at path. A missing file is
+// not an error and yields an empty config.
+func loadConfig(path string) (uniConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return uniConfig{}, nil
+		}
+		return uniConfig{}, err
+	}
+	values, errs := parseConfigValues(string(data))
+	if len(errs) > 0 {
+		return uniConfig{}, fmt.Errorf("%s: %w", path, errs[0])
+	}
+	var config uniConfig
+	if v, ok := values["manager"]; ok {
+		config.Manager, config.ManagerFile = v.Str, path
+	}
+	if v, ok := values["prefer"]; ok {
+		config.Prefer, config.PreferFile = v.List, path
+	}
+	return config, nil
+}
+
+// configPath replaces the project's .unirc as the config file read, set
+// with --config.
+var configPath string
+
+// globalConfigFile is read from the `uni` directory in os.UserConfigDir and
+// uses the same format as .unirc. It supplies defaults for every project.
+const globalConfigFile = "config"
+
+// projectConfigPath returns the config file for the current project.
+func projectConfigPath() string {
+	if configPath != "" {
+		return configPath
+	}
+	return projectPath(uniConfigFile)
+}
+
+// loadEffectiveConfig merges the global config with the project's, each
+// setting in the project file overriding the global one.
+func loadEffectiveConfig() (uniConfig, error) {
+	var config uniConfig
+	if configDir, err := os.UserConfigDir(); err == nil {
+		global, err := loadConfig(filepath.Join(configDir, "uni", globalConfigFile))
+		if err != nil {
+			return uniConfig{}, err
+		}
+		config = global
+	}
+
+	path := projectConfigPath()
+	if configPath != "" {
+		if _, err := os.Stat(path); err != nil {
+			return uniConfig{}, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	project, err := loadConfig(path)
+	if err != nil {
+		return uniConfig{}, err
+	}
+	if project.ManagerFile != "" {
+		config.Manager, config.ManagerFile = project.Manager, project.ManagerFile
+	}
+	if project.PreferFile != "" {
+		config.Prefer, config.PreferFile = project.Prefer, project.PreferFile
+	}
+	return config, nil
+}
+
+// configError is a problem found on a specific line of a config file.
+type configError struct {
+	Line int
+	Msg  string
+}
+
+func (e *configError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// parseConfigValues splits a config file into its assignments, reporting
+// every malformed line rather than stopping at the first.
+func parseConfigValues(data string) (map[string]configValue, []*configError) {
+	values := map[string]configValue{}
+	if trimmed := strings.TrimSpace(data); trimmed != "" && !strings.ContainsAny(trimmed, "=#\n") {
+		values["manager"] = configValue{Line: 1, Str: trimmed}
+		return values, nil
+	}
+	var errs []*configError
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(stripConfigComment(line))
+		if line == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			errs = append(errs, &configError{i + 1, fmt.Sprintf("expected `key = value`, got %q", line)})
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			errs = append(errs, &configError{i + 1, fmt.Sprintf("%s: %v", key, err)})
+			continue
+		}
+		if previous, ok := values[key]; ok {
+			errs = append(errs, &configError{i + 1, fmt.Sprintf("%s is already set on line %d", key, previous.Line)})
+			continue
+		}
+		value.Line = i + 1
+		values[key] = value
+	}
+	return values, errs
+}
+
+// configSchema lists the keys a config file may contain and whether each
+// holds an array rather than a single string. Every key names managers.
+var configSchema = map[string]bool{
+	"manager": false,
+	"prefer":  true,
+}
+
+// validateConfig checks a config file against configSchema and returns one
+// error per problem found, ordered by line.
+func validateConfig(data string) []*configError {
+	values, errs := parseConfigValues(data)
+	for key, value := range values {
+		isList, known := configSchema[key]
+		switch {
+		case !known:
+			errs = append(errs, &configError{value.Line, fmt.Sprintf("unknown key %q", key)})
+			continue
+		case isList && !value.IsList:
+			errs = append(errs, &configError{value.Line, key + " must be an array of strings"})
+			continue
+		case !isList && value.IsList:
+			errs = append(errs, &configError{value.Line, key + " must be a string"})
+			continue
+		}
+		names := value.List
+		if !isList {
+			names = []string{value.Str}
+		}
+		for _, name := range names {
+			if _, ok := supportedManagers[name]; !ok {
+				errs = append(errs, &configError{value.Line, fmt.Sprintf("%s: '%s' is not a supported package manager", key, name)})
+			}
+		}
+	}
+	slices.SortStableFunc(errs, func(a, b *configError) int { return a.Line - b.Line })
+	return errs
+}
+
+func parseConfigValue(raw string) (configValue, error) {
+	if inner, ok := strings.CutPrefix(raw, "["); ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+		if !ok {
+			return configValue{}, fmt.Errorf("unterminated array %s", raw)
+		}
+		value := configValue{IsList: true, List: []string{}}
+		for _, item := range strings.Split(inner, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			str, err := unquoteConfigString(item)
+			if err != nil {
+				return configValue{}, err
+			}
+			value.List = append(value.List, str)
+		}
+		return value, nil
+	}
+	str, err := unquoteConfigString(raw)
+	if err != nil {
+		return configValue{}, err
+	}
+	return configValue{Str: str}, nil
+}
+
+func unquoteConfigString(raw string) (string, error) {
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		return raw[1 : len(raw)-1], nil
+	}
+	return "", fmt.Errorf("expected a quoted string, got %s", raw)
+}
+
+// stripConfigComment drops a trailing `#` comment that isn't inside quotes.
+func stripConfigComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// handleConfigValidate implements `uni config validate [file]`.
+func handleConfigValidate(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		exitWithError(&uniError{Category: ErrConfig, Err: fmt.Errorf("could not read %s: %w", path, err)})
+	}
+	errs := validateConfig(string(data))
+	if len(errs) == 0 {
+		color.Green("✅ %s is valid.", path)
+		return
+	}
+	color.Red("%s has %d problem(s):", path, len(errs))
+	for _, err := range errs {
+		color.Red("  %s:%d: %s", path, err.Line, err.Msg)
+	}
+	exitWithError(&uniError{Category: ErrConfig, Err: fmt.Errorf("%s has %d problem(s)", path, len(errs)), Quiet: true})
+}
+
+// handleConfigGet implements `uni config get <key>`, printing the effective
+// value with list items one per line.
+func handleConfigGet(key string) {
+	if _, known := configSchema[key]; !known {
+		exitWithError(newError(ErrUsage, "unknown config key %q; known keys are %s", key, strings.Join(slices.Sorted(maps.Keys(configSchema)), ", ")))
+	}
+	config, err := loadEffectiveConfig()
+	if err != nil {
+		exitWithError(&uniError{Category: ErrConfig, Err: err})
+	}
+	var values []string
+	switch key {
+	case "manager":
+		if config.Manager != "" {
+			values = []string{config.Manager}
+		}
+	case "prefer":
+		values = config.Prefer
+	}
+	if values == nil {
+		exitWithError(newError(ErrConfig, "%s is not set", key))
+	}
+	for _, value := range values {
+		fmt.Println(value)
+	}
+}
+
+// handleConfigSet implements `uni config set <key> <value...>`. Values are
+// checked against configSchema before the project config is touched; list
+// keys take several values or one comma-separated value.
+func handleConfigSet(key string, values []string) {
+	isList, known := configSchema[key]
+	if !known {
+		exitWithError(newError(ErrUsage, "unknown config key %q; known keys are %s", key, strings.Join(slices.Sorted(maps.Keys(configSchema)), ", ")))
+	}
+	if isList && len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	if !isList && len(values) != 1 {
+		exitWithError(newError(ErrUsage, "%s takes a single value", key))
+	}
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+		if _, ok := supportedManagers[values[i]]; !ok {
+			exitWithError(newError(ErrManagerUnsupported, "'%s' is not a supported package manager", values[i]))
+		}
+	}
+
+	literal := strconv.Quote(values[0])
+	if isList {
+		quoted := make([]string, len(values))
+		for i, value := range values {
+			quoted[i] = strconv.Quote(value)
+		}
+		literal = "[" + strings.Join(quoted, ", ") + "]"
+	}
+	path := projectConfigPath()
+	if err := setConfigValue(path, key, literal); err != nil {
+		exitWithError(&uniError{Category: ErrConfig, Err: fmt.Errorf("could not update %s: %w", path, err)})
+	}
+	color.Green("✅ Set %s = %s in %s.", key, literal, path)
+}
+
+// setConfigValue sets key to value, a TOML string or array literal, in the
+// config file at path, keeping every other line as it is. A file in the
+// legacy bare-manager format is converted first.
+func setConfigValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := string(data)
+	if values, errs := parseConfigValues(content); len(errs) == 0 && !strings.Contains(content, "=") {
+		if legacy, ok := values["manager"]; ok {
+			content = fmt.Sprintf("manager = %q\n", legacy.Str)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+	assignment := key + " = " + value
+	replaced := false
+	for i, line := range lines {
+		lineKey, _, ok := strings.Cut(stripConfigComment(line), "=")
+		if ok && strings.TrimSpace(lineKey) == key {
+			lines[i] = assignment
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append(lines, assignment)
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// userManagersFile is read from the `uni` directory in os.UserConfigDir. It
+// holds a JSON object mapping manager keys to PackageManagerInfo fields:
+//
+//	{"cargo": {"name": "Cargo", "executable": "cargo", "lockFiles": ["Cargo.lock"], "installCmd": "add"}}
+const userManagersFile = "managers.json"
+
+// loadUserManagers merges user-defined managers into supportedManagers.
+// Invalid entries and ones clashing with a built-in manager are skipped with
+// a warning so a bad plugin file never breaks uni itself.
+func loadUserManagers() {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return
+	}
+	path := filepath.Join(configDir, "uni", userManagersFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			color.Yellow("Warning: could not read %s: %v", path, err)
+		}
+		return
+	}
+	var managers map[string]PackageManagerInfo
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&managers); err != nil {
+		color.Yellow("Warning: ignoring %s: %v", path, err)
+		return
+	}
+	for key, pm := range managers {
+		_, builtIn := supportedManagers[key]
+		switch {
+		case key == "" || strings.ContainsAny(key, " \t="):
+			color.Yellow("Warning: %s: '%s' is not a valid manager key, skipping it.", path, key)
+		case pm.Name == "" || pm.Executable == "":
+			color.Yellow("Warning: %s: manager '%s' needs both a name and an executable, skipping it.", path, key)
+		case builtIn:
+			color.Yellow("Warning: %s: '%s' conflicts with a built-in manager, keeping the built-in.", path, key)
+		default:
+			supportedManagers[key] = pm
+		}
+	}
+}
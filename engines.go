@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// checkEngines compares the active Node.js with the version a project
+// declares before node-family installs, set with --check-engines.
+var checkEngines bool
+
+// checkNodeEngines warns when `node --version` doesn't match .nvmrc or the
+// engines.node range in package.json. It never stops the install.
+func checkNodeEngines() {
+	cmd := exec.Command("node", "--version")
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		color.Yellow("Warning: --check-engines could not run node: %v", err)
+		return
+	}
+	active := strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+	running, ok := parseVersion(active)
+	if !ok {
+		return
+	}
+
+	if data, err := os.ReadFile(projectPath(".nvmrc")); err == nil {
+		want := strings.TrimSpace(string(data))
+		// Aliases such as lts/* or node name no fixed version.
+		if _, ok := parseVersion(want); ok && !matchesVersionRange(running, strings.TrimPrefix(want, "v")) {
+			color.Yellow("Warning: .nvmrc asks for Node %s, but node %s is active.", want, active)
+		}
+	}
+
+	data, err := os.ReadFile(projectPath("package.json"))
+	if err != nil {
+		return
+	}
+	var manifest struct {
+		Engines struct {
+			Node string `json:"node"`
+		} `json:"engines"`
+	}
+	if json.Unmarshal(data, &manifest) == nil && manifest.Engines.Node != "" && !matchesVersionRange(running, manifest.Engines.Node) {
+		color.Yellow("Warning: package.json engines.node wants %s, but node %s is active.", manifest.Engines.Node, active)
+	}
+}
+
+// matchesVersionRange reports whether version satisfies an npm-style range:
+// `||` alternatives of space-separated comparators using >=, >, <=, <, =,
+// ^ and ~, x-ranges such as 18.x, and hyphen ranges like 16 - 18.
+// Pre-release tags are ignored.
+func matchesVersionRange(version []int, rng string) bool {
+	for _, alt := range strings.Split(rng, "||") {
+		var comparators []string
+		for _, field := range strings.Fields(alt) {
+			// `>= 18` is the same comparator as `>=18`.
+			if n := len(comparators); n > 0 && strings.Trim(comparators[n-1], "<>=^~") == "" {
+				comparators[n-1] += field
+				continue
+			}
+			comparators = append(comparators, field)
+		}
+		if len(comparators) == 3 && comparators[1] == "-" {
+			comparators = []string{">=" + comparators[0], "<=" + comparators[2]}
+		}
+		matched := true
+		for _, c := range comparators {
+			if !matchesComparator(version, c) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesComparator(version []int, comparator string) bool {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if rest, ok := strings.CutPrefix(comparator, prefix); ok {
+			op, comparator = prefix, rest
+			break
+		}
+	}
+	want, n := parsePartialVersion(strings.TrimPrefix(comparator, "v"))
+	if n == 0 {
+		// *, x or a bare operator match anything.
+		return true
+	}
+	switch op {
+	case ">=":
+		return comparePrefix(version, want, n) >= 0
+	case ">":
+		return comparePrefix(version, want, n) > 0
+	case "<=":
+		return comparePrefix(version, want, n) <= 0
+	case "<":
+		return comparePrefix(version, want, n) < 0
+	case "^":
+		// Changes are allowed right of the first non-zero part.
+		fixed := n
+		for i, part := range want[:n] {
+			if part != 0 {
+				fixed = i + 1
+				break
+			}
+		}
+		return comparePrefix(version, want, n) >= 0 && comparePrefix(version, want, fixed) == 0
+	case "~":
+		return comparePrefix(version, want, n) >= 0 && comparePrefix(version, want, min(n, 2)) == 0
+	default:
+		return comparePrefix(version, want, n) == 0
+	}
+}
+
+// parsePartialVersion parses up to three numeric parts of a version,
+// stopping at an x or * wildcard, and returns how many it read.
+func parsePartialVersion(v string) ([]int, int) {
+	v, _, _ = strings.Cut(v, "-")
+	parts := make([]int, 3)
+	n := 0
+	for _, field := range strings.SplitN(v, ".", 3) {
+		num, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts[n] = num
+		n++
+	}
+	return parts, n
+}
+
+// comparePrefix compares the first n parts of two versions, treating missing
+// parts as zero.
+func comparePrefix(a, b []int, n int) int {
+	for i := range n {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
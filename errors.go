@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Error categories let scripts tell failures apart through the exit code
+// (see exitCodes) or the `code` field of --json error output.
+var (
+	ErrUsage               = errors.New("invalid usage")
+	ErrManagerUnsupported  = errors.New("package manager not supported")
+	ErrManagerNotInstalled = errors.New("package manager not installed")
+	ErrNetwork             = errors.New("network error")
+	ErrConfig              = errors.New("invalid configuration")
+	ErrCommandFailed       = errors.New("command failed")
+)
+
+// exitCodes maps each error category to its machine-readable code and the
+// process exit code. Uncategorized errors exit with 1 and code "error".
+var exitCodes = []struct {
+	Err  error
+	Code string
+	Exit int
+}{
+	{ErrCommandFailed, "command_failed", 1},
+	{ErrUsage, "usage", 2},
+	{ErrManagerUnsupported, "manager_unsupported", 3},
+	{ErrManagerNotInstalled, "manager_not_installed", 4},
+	{ErrNetwork, "network", 5},
+	{ErrConfig, "config", 6},
+}
+
+// uniError attaches a category, and optionally a hint for the user, to an
+// error.
+type uniError struct {
+	Category error
+	Err      error
+	Hint     string
+	// Quiet marks errors that were already reported, e.g. by the package
+	// manager's own output, so they aren't printed twice.
+	Quiet bool
+}
+
+func (e *uniError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *uniError) Unwrap() []error {
+	return []error{e.Category, e.Err}
+}
+
+func newError(category error, format string, a ...any) *uniError {
+	return &uniError{Category: category, Err: fmt.Errorf(format, a...)}
+}
+
+func usageError(usage string) *uniError {
+	return &uniError{Category: ErrUsage, Err: errors.New("usage: " + usage)}
+}
+
+// exitWithError reports err, as JSON when --json is set, and exits with the
+// code for its category.
+func exitWithError(err error) {
+	code, exit := "error", 1
+	for _, c := range exitCodes {
+		if errors.Is(err, c.Err) {
+			code, exit = c.Code, c.Exit
+			break
+		}
+	}
+	var uniErr *uniError
+	errors.As(err, &uniErr)
+
+	if jsonOutput {
+		payload := map[string]string{"code": code, "message": err.Error()}
+		if uniErr != nil && uniErr.Hint != "" {
+			payload["hint"] = uniErr.Hint
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(map[string]any{"error": payload})
+		os.Exit(exit)
+	}
+
+	switch {
+	case uniErr != nil && uniErr.Quiet:
+	case errors.Is(err, ErrUsage) && strings.HasPrefix(err.Error(), "usage: "):
+		color.Red("Usage: %s", strings.TrimPrefix(err.Error(), "usage: "))
+	default:
+		color.Red("Error: %v", err)
+	}
+	if uniErr != nil && uniErr.Hint != "" && !uniErr.Quiet {
+		color.Yellow("Hint: %s", uniErr.Hint)
+	}
+	os.Exit(exit)
+}
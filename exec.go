@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// execCacheFile is kept in uniCacheDir. It remembers the runner `uni x`
+// resolved for each project and tool, so repeated runs skip detection and
+// version probes.
+const execCacheFile = "exec-cache.json"
+
+// execCacheEntry is a resolved runner. It stays valid while the project
+// file that decided the manager is unchanged.
+type execCacheEntry struct {
+	Manager string    `json:"manager"`
+	Runner  []string  `json:"runner"`
+	File    string    `json:"file"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// loadExecCache reads the runner cache. A missing or corrupt cache is
+// treated as empty.
+func loadExecCache() map[string]execCacheEntry {
+	cache := map[string]execCacheEntry{}
+	if !readCacheJSON(execCacheFile, &cache) {
+		return map[string]execCacheEntry{}
+	}
+	return cache
+}
+
+func saveExecCache(cache map[string]execCacheEntry) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeCacheFile(execCacheFile, data)
+}
+
+// execCacheKey identifies a runner by project directory, requested manager
+// and tool.
+func execCacheKey(specifiedManager, tool string) string {
+	dir, err := filepath.Abs(projectPath("."))
+	if err != nil {
+		dir = workDir
+	}
+	return strings.Join([]string{dir, specifiedManager, tool}, "|")
+}
+
+func (e execCacheEntry) valid() bool {
+	info, err := os.Stat(projectPath(e.File))
+	return err == nil && info.ModTime().Equal(e.ModTime) && len(e.Runner) > 0
+}
+
+// execRunner returns the command prefix that runs a package without
+// installing it, e.g. `npx` or `pnpm dlx`. pkgx's runner is pkgx itself,
+// which fetches and runs the tool in one step.
+func execRunner(pm PackageManagerInfo) ([]string, error) {
+	pm = resolveVariant(pm)
+	if pm.Variant == "classic" {
+		// Yarn 1 has no dlx, but the packages it installs run the same
+		// under npx, which comes with Node.
+		if _, err := lookPathCached("npx"); err == nil {
+			logVerbose("Yarn 1 has no dlx, running packages with npx instead")
+			return []string{"npx"}, nil
+		}
+		err := newError(ErrManagerUnsupported, "Yarn 1 can't run a package without installing it: dlx needs Yarn 2 or later")
+		err.Hint = "Install npx, which comes with npm, or upgrade Yarn with 'yarn set version stable'."
+		return nil, err
+	}
+	if pm.ExecutionCmd == "" {
+		return nil, newError(ErrManagerUnsupported, "%s can't run a package without installing it", pm.Name)
+	}
+	switch pm.Name {
+	case "PNPM", "Yarn":
+		return []string{pm.Executable, pm.ExecutionCmd}, nil
+	default:
+		return strings.Fields(pm.ExecutionCmd), nil
+	}
+}
+
+// resolveExecRunner finds the runner for tool, from the cache unless
+// noCache is set.
+func resolveExecRunner(specifiedManager, tool string, noCache bool) (PackageManagerInfo, []string, error) {
+	key := execCacheKey(specifiedManager, tool)
+	cache := loadExecCache()
+	if entry, ok := cache[key]; ok && !noCache && entry.valid() {
+		if pm, ok := supportedManagers[entry.Manager]; ok {
+			recordStats(func(stats *usageStats) { stats.ExecCacheHits++ })
+			return pm, entry.Runner, nil
+		}
+	}
+	if !noCache {
+		recordStats(func(stats *usageStats) { stats.ExecCacheMisses++ })
+	}
+
+	result, err := reportDetection(specifiedManager)
+	if err != nil {
+		return PackageManagerInfo{}, nil, err
+	}
+	runner, err := execRunner(result.Manager)
+	if err != nil {
+		return PackageManagerInfo{}, nil, err
+	}
+	// Only choices made from a project file can be checked for staleness.
+	if result.File != "" {
+		if info, err := os.Stat(projectPath(result.File)); err == nil {
+			cache[key] = execCacheEntry{Manager: result.Key, Runner: runner, File: result.File, ModTime: info.ModTime()}
+			if err := saveExecCache(cache); err != nil {
+				color.Yellow("Warning: could not save the runner cache: %v", err)
+			}
+		}
+	}
+	return result.Manager, runner, nil
+}
+
+// pinToolVersion adds a --version pin to tool. Every runner uni uses, from
+// npx and the dlx commands to bunx and pkgx, takes `tool@version`, including
+// for scoped `@scope/tool` names.
+func pinToolVersion(tool, version string) (string, error) {
+	if strings.Contains(strings.TrimPrefix(tool, "@"), "@") {
+		return "", newError(ErrUsage, "'%s' already names a version; drop it or --version", tool)
+	}
+	return tool + "@" + version, nil
+}
+
+// handleExec implements `uni x`, running a package through the manager's
+// runner.
+func handleExec(specifiedManager string, args []string) {
+	const usage = "uni x [--no-cache] [--version=<ver>] <command> [args...]"
+	var noCache bool
+	var version string
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		if args[0] == "--no-cache" {
+			noCache = true
+		} else if v, ok := strings.CutPrefix(args[0], "--version="); ok && v != "" {
+			version = v
+		} else {
+			break
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		exitWithError(usageError(usage))
+	}
+	manager, runner, err := resolveExecRunner(specifiedManager, args[0], noCache)
+	if err != nil {
+		exitWithError(err)
+	}
+	if version != "" {
+		tool, err := pinToolVersion(args[0], version)
+		if err != nil {
+			exitWithError(err)
+		}
+		args = append([]string{tool}, args[1:]...)
+	}
+
+	color.Cyan("▶️  Executing command: %s", commandLine(runner[0], append(runner[1:], args...)))
+	cmd, _, cancel := newCliCommand(nil, runner[0], append(runner[1:], args...)...)
+	defer cancel()
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			exitWithError(&uniError{Category: ErrManagerNotInstalled, Err: fmt.Errorf("executing command: %w", err), Hint: manager.InstallationHint})
+		}
+		exitWithError(&uniError{Category: ErrCommandFailed, Err: fmt.Errorf("executing command: %w", err)})
+	}
+}
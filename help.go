@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// helpTopic is the detailed help `uni help <command>` prints for one
+// command. perManager, when set, describes what the command runs for each
+// supported manager; managers it returns "" for are left out.
+type helpTopic struct {
+	usage      []string
+	summary    string
+	flags      []string
+	notes      string
+	perManager func(pm PackageManagerInfo) string
+}
+
+// helpAliases maps command aliases to the topic that documents them.
+var helpAliases = map[string]string{
+	"i": "install", "add": "install",
+	"rm": "uninstall", "un": "uninstall", "remove": "uninstall",
+	"s":      "search",
+	"exec":   "x",
+	"ls":     "list",
+	"export": "freeze",
+	"ddp":    "dedupe",
+}
+
+var helpTopics = map[string]helpTopic{
+	"install": {
+		usage:   []string{"uni install [flags] [package...]"},
+		summary: "Install packages with the detected manager, or everything the project lists when no package is given.",
+		flags: []string{
+			"-                     Read package names from stdin, one per line",
+			"--frozen, --immutable Install exactly what the lockfile lists",
+			"--manifest-only       Update the manifest and lockfile without downloading",
+			"--no-lockfile         Install without writing a lockfile",
+			"--exact, -E           Save exact versions instead of caret ranges (npm, pnpm, Yarn, Bun)",
+			"--include-prerelease  Allow pre-releases: --pre for pip, npm's next tag when newer than latest",
+			"--ignore-scripts      Skip package install scripts",
+			"--prefer-offline      Install from the manager's cache before the network",
+			"--preview             Summarize what a dry run would add, change or remove",
+			"--summary             Report how many packages changed and how long it took",
+			"--retry-failed        With -, install one package at a time and retry failures",
+			"--cask, --formula     Pick the Homebrew package type when both exist",
+		},
+		notes: "Git URLs such as https://github.com/user/repo#v1.2 are translated to each manager's git dependency syntax. Go packages with a /cmd/ path, or a version outside a module, are installed with 'go install'.",
+		perManager: func(pm PackageManagerInfo) string {
+			switch {
+			case pm.InstallCmd == "" && pm.InstallCmdWithoutArgs == "":
+				return "not supported"
+			case pm.InstallCmd == "":
+				return fmt.Sprintf("%s %s (add packages to the manifest by hand)", pm.Executable, pm.InstallCmdWithoutArgs)
+			case pm.InstallCmdWithoutArgs == "":
+				return fmt.Sprintf("%s %s <package...>", pm.Executable, pm.InstallCmd)
+			}
+			return fmt.Sprintf("%s %s <package...>, or %s %s", pm.Executable, pm.InstallCmd, pm.Executable, pm.InstallCmdWithoutArgs)
+		},
+	},
+	"uninstall": {
+		usage:   []string{"uni uninstall <package...>", "uni uninstall --packages-from=<file> [--yes]"},
+		summary: "Remove packages from the project.",
+		flags: []string{
+			"--packages-from=<file> Remove every package listed in a file, one per line",
+			"--yes                  Don't ask before removing a --packages-from list",
+		},
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.UninstallCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.UninstallCmd + " <package...>"
+		},
+	},
+	"search": {
+		usage:   []string{"uni search [flags] <query>", "uni --pkg=all search [flags] <query>"},
+		summary: "Search a registry through its API, or the manager's own search command where it has no API.",
+		flags: []string{
+			"--open[=N]            Open the homepage of the first (or Nth) result",
+			"--exact               Only show a package named exactly like the query",
+			"--since=<30d|2w|12h>  Hide npm packages not published recently",
+			"--min-score=<0..1>    Hide npm results with a lower search score",
+			"--registry=<url>      Search a different npm registry this once",
+			"--tap=<user/repo>     Only show Homebrew results from that tap",
+			"--cask, --formula     Only show Homebrew results of that type",
+			"--max-desc=N          Cut descriptions to N characters (default 120, 0 for all)",
+			"--output=<file>       Write the results to a file",
+			"--latest-only         Look up each result's latest version (npm, CocoaPods, Hackage)",
+			"--include-prerelease  Show pre-release versions, e.g. npm's next tag or Hex betas",
+			"--json-lines          Print one JSON object per result as results arrive",
+			"--dedupe-results      Merge --pkg=all results sharing a name",
+		},
+		notes: "In a terminal, pick a numbered result to install it.",
+		perManager: func(pm PackageManagerInfo) string {
+			if !pm.SearchAPISupport {
+				return ""
+			}
+			switch pm.Name {
+			case "NPM", "PNPM", "Yarn", "Bun":
+				return "the npm registry search API"
+			case "Homebrew", "Snap", "Flatpak", "vcpkg", "Conan":
+				return pm.Executable + " search, parsed locally"
+			}
+			return "the registry's search API"
+		},
+	},
+	"x": {
+		usage:   []string{"uni x [--no-cache] [--version=<ver>] <command> [args...]"},
+		summary: "Run a package without installing it into the project.",
+		flags: []string{
+			"--no-cache            Detect the runner again instead of using the cached one",
+			"--version=<ver>       Run that version of the package, e.g. create-vite@5",
+		},
+		notes: "Yarn 1 has no dlx, so Yarn 1 projects run packages with npx.",
+		perManager: func(pm PackageManagerInfo) string {
+			runner, err := execRunner(pm)
+			if err != nil {
+				return ""
+			}
+			return strings.Join(runner, " ") + " <command>"
+		},
+	},
+	"init": {
+		usage:   []string{"uni init <manager>", "uni init --check <manager>"},
+		summary: "Set the project up for a manager: write .unirc selecting it and run its own init.",
+		notes:   "Given node, python or ruby, init asks which manager of that family to use, suggesting the one the project's files point at. Without a terminal it takes that suggestion, or the family's first installed manager.",
+		flags: []string{
+			"--check               Report whether the project is already set up, changing nothing",
+		},
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.InitArgs == nil {
+				return "only writes .unirc"
+			}
+			return pm.Executable + " " + strings.Join(pm.InitArgs, " ")
+		},
+	},
+	"run": {
+		usage:   []string{"uni run <script> [-- args...]"},
+		summary: "Run a package script. Arguments after -- go to the script; npm keeps the --, while pnpm, Yarn and Bun drop it since they already forward everything after the script name.",
+	},
+	"list": {
+		usage:   []string{"uni list [--tree] [--depth=N]"},
+		summary: "List installed dependencies.",
+		flags: []string{
+			"--tree                Show the dependency tree where the manager has one",
+			"--depth=N             Limit the tree to N levels",
+		},
+		perManager: func(pm PackageManagerInfo) string {
+			switch {
+			case pm.ListCmd == "" && pm.TreeCmd == "":
+				return ""
+			case pm.TreeCmd == "":
+				return pm.Executable + " " + pm.ListCmd
+			case pm.ListCmd == "":
+				return pm.Executable + " " + pm.TreeCmd + " (tree only)"
+			}
+			return fmt.Sprintf("%s %s, --tree: %s %s", pm.Executable, pm.ListCmd, pm.Executable, pm.TreeCmd)
+		},
+	},
+	"outdated": {
+		usage:   []string{"uni outdated", "uni outdated --all-managers"},
+		summary: "List dependencies with newer versions available.",
+		flags: []string{
+			"--all-managers        Check every manager with files in the project",
+		},
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.OutdatedCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.OutdatedCmd
+		},
+	},
+	"which": {
+		usage:   []string{"uni which <package>"},
+		summary: "Show where an installed package lives and its version. Node managers look through node_modules directly.",
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.WhichCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.WhichCmd + " <package>"
+		},
+	},
+	"freeze": {
+		usage:   []string{"uni freeze [--output=<file>]"},
+		summary: "Snapshot installed dependencies, to stdout or a file.",
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.FreezeCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.FreezeCmd
+		},
+	},
+	"reinstall": {
+		usage:   []string{"uni reinstall [--force] <package...>"},
+		summary: "Reinstall packages, with the manager's own reinstall where it has one and otherwise an uninstall followed by an install.",
+		flags: []string{
+			"--force               Install even if the removal fails",
+		},
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.ReinstallCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.ReinstallCmd + " <package...>"
+		},
+	},
+	"dedupe": {
+		usage:   []string{"uni dedupe"},
+		summary: "Collapse duplicated dependencies.",
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.DedupeCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.DedupeCmd
+		},
+	},
+	"prune": {
+		usage:   []string{"uni prune"},
+		summary: "Remove packages that aren't in the manifest.",
+		perManager: func(pm PackageManagerInfo) string {
+			if pm.PruneCmd == "" {
+				return ""
+			}
+			return pm.Executable + " " + pm.PruneCmd
+		},
+	},
+	"config": {
+		usage:   []string{"uni config validate [file]", "uni config get <key>", "uni config set <key> <value...>"},
+		summary: "Check or change .unirc. get takes the global config into account; set keeps the rest of the file.",
+	},
+	"detect": {
+		usage:   []string{"uni detect [--json]"},
+		summary: "Show which manager would be used and why: --pkg, .unirc, package.json's packageManager, lockfiles, .tool-versions, then manifests.",
+	},
+	"migrate": {
+		usage:   []string{"uni migrate <manager>"},
+		summary: "Switch the project to another manager: delete the old lockfiles, select the new manager in .unirc and reinstall with it.",
+		notes:   "The new manager must be installed and in the same ecosystem (node, python or ruby); otherwise nothing is changed.",
+	},
+	"rollback": {
+		usage:   []string{"uni rollback"},
+		summary: "Restore the lockfile from git HEAD and reinstall from it.",
+	},
+	"bundle": {
+		usage:   []string{"uni bundle [--retry-failed] <file>"},
+		summary: "Install every package listed in a file, one per line and one at a time, then report any that failed. Prefix a line with a manager to use it for that package, e.g. 'brew: git'. A Brewfile is handed to brew bundle.",
+		flags: []string{
+			"--retry-failed        Retry the failed packages once the rest are done",
+		},
+	},
+	"cache": {
+		usage:   []string{"uni cache verify"},
+		summary: "Check the files uni caches between runs, such as the uni x runner cache. Files that aren't valid JSON, temp files left by interrupted writes and runner entries naming unknown managers are removed.",
+	},
+	"stats": {
+		usage:   []string{"uni stats [--json]"},
+		summary: "Show the cache's size, the uni x runner cache's hit rate, the most searched queries and how often each manager was detected. The counters live in the cache dir; set UNI_NO_STATS=1 to stop recording them.",
+	},
+	"doctor": {
+		usage:   []string{"uni doctor"},
+		summary: "Check which package managers are installed and working.",
+	},
+	"self-update": {
+		usage:   []string{"uni self-update [--check-only]"},
+		summary: "Update uni to the latest release.",
+		flags: []string{
+			"--check-only          Only report whether a newer release exists",
+		},
+	},
+	"managers": {
+		usage:   []string{"uni managers [--json]"},
+		summary: "List supported package managers and whether they're installed.",
+	},
+}
+
+// handleHelp implements `uni help [command]`.
+func handleHelp(args []string) {
+	if len(args) == 0 {
+		printHelp()
+		return
+	}
+	if len(args) > 1 {
+		exitWithError(usageError("uni help [command]"))
+	}
+	name := args[0]
+	if alias, ok := helpAliases[name]; ok {
+		name = alias
+	}
+	topic, ok := helpTopics[name]
+	if !ok {
+		names := make([]string, 0, len(helpTopics))
+		for name := range helpTopics {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		err := newError(ErrUsage, "no help for '%s'", args[0])
+		err.Hint = "Help is available for: " + strings.Join(names, ", ") + "."
+		exitWithError(err)
+	}
+
+	fmt.Println(color.YellowString("Usage:"))
+	for _, usage := range topic.usage {
+		fmt.Println("  " + usage)
+	}
+	fmt.Println("\n" + topic.summary)
+	if len(topic.flags) > 0 {
+		fmt.Println("\n" + color.YellowString("Flags:"))
+		for _, line := range topic.flags {
+			fmt.Println("  " + line)
+		}
+	}
+	if topic.notes != "" {
+		fmt.Println("\n" + topic.notes)
+	}
+	if topic.perManager == nil {
+		return
+	}
+	fmt.Println("\n" + color.YellowString("Per manager:"))
+	for _, key := range orderedManagerKeys() {
+		if line := topic.perManager(supportedManagers[key]); line != "" {
+			fmt.Printf("  %-10s %s\n", key, line)
+		}
+	}
+}
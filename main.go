@@ -3,32 +3,52 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 type NPMRegistrySearchResult struct {
-	Objects []struct {
-		Package struct {
-			Name        string `json:"name"`
-			Description string `json:"description"`
-			Version     string `json:"version"`
-			Links       struct {
-				Homepage string `json:"homepage"`
-			} `json:"links"`
-			Author struct {
-				Name string `json:"name"`
-			} `json:"author"`
-		} `json:"package"`
-	} `json:"objects"`
+	Objects []NPMSearchObject `json:"objects"`
+	Total   int               `json:"total"`
+}
+
+type NPMSearchObject struct {
+	Package struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+		Date        string `json:"date"`
+		Links       struct {
+			Homepage string `json:"homepage"`
+		} `json:"links"`
+		Author struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"package"`
+	Score struct {
+		Final float64 `json:"final"`
+	} `json:"score"`
 }
 
 type BrewCliInfoResponse struct {
@@ -38,12 +58,14 @@ type BrewCliInfoResponse struct {
 		Desc     string `json:"desc"`
 		License  string `json:"license"`
 		Homepage string `json:"homepage"`
+		Tap      string `json:"tap"`
 	} `json:"formulae"`
 	Casks []struct {
 		Token    string `json:"token"`
 		FullName string `json:"full_name"`
 		Desc     string `json:"desc"`
 		Homepage string `json:"homepage"`
+		Tap      string `json:"tap"`
 	} `json:"casks"`
 }
 
@@ -59,6 +81,98 @@ type CocoaPodsAPISearchResult struct {
 	Total int `json:"total"`
 }
 
+type SwiftPackageIndexSearchResult struct {
+	Results []struct {
+		Package *struct {
+			PackageName     string `json:"packageName"`
+			PackageURL      string `json:"packageURL"`
+			RepositoryName  string `json:"repositoryName"`
+			RepositoryOwner string `json:"repositoryOwner"`
+			Summary         string `json:"summary"`
+		} `json:"package"`
+	} `json:"results"`
+}
+
+type JSRSearchResult struct {
+	Items []struct {
+		Scope         string `json:"scope"`
+		Name          string `json:"name"`
+		Description   string `json:"description"`
+		LatestVersion string `json:"latestVersion"`
+	} `json:"items"`
+	Total int `json:"total"`
+}
+
+type GoProxyLatestResponse struct {
+	Version string `json:"Version"`
+	Time    string `json:"Time"`
+}
+
+type PkgxPantryEntry struct {
+	Project     string `json:"project"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type HexPackage struct {
+	Name                string `json:"name"`
+	LatestVersion       string `json:"latest_version"`
+	LatestStableVersion string `json:"latest_stable_version"`
+	HTMLURL             string `json:"html_url"`
+	Meta                struct {
+		Description string   `json:"description"`
+		Licenses    []string `json:"licenses"`
+	} `json:"meta"`
+}
+
+type HackageSearchResult struct {
+	NumberOfResults int `json:"numberOfResults"`
+	PageContents    []struct {
+		Name struct {
+			Display string `json:"display"`
+			URI     string `json:"uri"`
+		} `json:"name"`
+		Description string `json:"description"`
+	} `json:"pageContents"`
+}
+
+// PackageResult is one search result, filled in by every searcher and
+// rendered by printPackageInfo or as JSON. Empty fields are left out of both.
+type PackageResult struct {
+	Name        string   `json:"name"`
+	Scope       string   `json:"scope,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Type        string   `json:"type,omitempty"`    // Homebrew "Formula" or "Cask"
+	Tap         string   `json:"tap,omitempty"`     // Homebrew tap the result comes from
+	Aliases     string   `json:"aliases,omitempty"` // Other names the search matched it by
+	Author      string   `json:"author,omitempty"`  // Also the publisher or owner, where that's what the source lists
+	License     string   `json:"license,omitempty"`
+	Homepage    string   `json:"homepage,omitempty"`
+	Source      string   `json:"source,omitempty"`    // Repository URL, or the remote for Flatpak and Conan
+	Published   string   `json:"published,omitempty"` // RFC 3339 time of the latest release
+	Registry    string   `json:"registry"`            // Manager whose search found it
+	Providers   []string `json:"providers,omitempty"` // Every manager offering the name, set by --dedupe-results
+	Note        string   `json:"note,omitempty"`
+}
+
+// fields lists the populated fields in display order. Registry is left out
+// since the search header already names it.
+func (r PackageResult) fields() [][2]string {
+	var fields [][2]string
+	for _, field := range [][2]string{
+		{"Name", r.Name}, {"Scope", r.Scope}, {"Description", r.Description}, {"Version", r.Version},
+		{"Type", r.Type}, {"Tap", r.Tap}, {"Aliases", r.Aliases}, {"Author", r.Author}, {"License", r.License},
+		{"Homepage", r.Homepage}, {"Source", r.Source}, {"Published", r.Published},
+		{"Providers", strings.Join(r.Providers, ", ")}, {"Note", r.Note},
+	} {
+		if field[1] != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 type PackageManagerInfo struct {
 	Name                  string
 	Executable            string
@@ -68,361 +182,4217 @@ type PackageManagerInfo struct {
 	InstallCmd            string
 	InstallCmdWithoutArgs string // For commands like `npm install` without additional args
 	ExecutionCmd          string // For commands like `npx <command>` or `bunx <command>`
+	FrozenCmd             string // Lockfile-only install used by `uni install --frozen`, e.g. `npm ci`
+	DryRunFlag            string // Install flag reporting planned changes, used by `uni install --preview`
+	IgnoreScriptsFlag     string // Skips package lifecycle scripts during install, e.g. `--ignore-scripts`
+	PreferOfflineFlag     string // Installs from the local cache where possible, e.g. `--prefer-offline`
+	NoLockfileFlag        string // Installs without writing a lockfile, e.g. `--no-package-lock`
+	PrereleaseFlag        string // Lets install pick pre-release versions, e.g. pip's `--pre`
+	ExactFlag             string // Saves the exact version instead of a caret range, e.g. `--save-exact`
+	ManifestOnlyFlag      string // Install flag that updates the manifest/lockfile without downloading
 	UninstallCmd          string
+	DedupeCmd             string // Collapses duplicate dependencies, e.g. `npm dedupe`
+	PruneCmd              string // Removes packages not listed in the manifest, e.g. `npm prune`
+	ReinstallCmd          string // Native reinstall; without one uni uninstalls then installs
+	FreezeCmd             string // Prints a snapshot of installed dependencies, e.g. `pip freeze`
+	ListCmd               string // Lists installed dependencies for `uni list`, e.g. `npm ls`
+	TreeCmd               string // Prints the dependency tree for `uni list --tree`, e.g. `go mod graph`
+	DepthFlag             string // Tree depth flag for `uni list --depth=N`, followed directly by N
+	WhichCmd              string // Shows where one installed package lives, e.g. `pip show`
+	OutdatedCmd           string // Lists dependencies with newer versions, e.g. `pip list --outdated`
 	SearchAPISupport      bool
 	InstallationHint      string
+	ManualInstallHint     string // Shown when packages are added by editing the manifest instead of a command
+	VersionCmd            string // Prints the manager's version; defaults to `--version`
+	Variant               string // Flavor found by resolveVariant, e.g. "classic" for Yarn 1
 }
 
 var supportedManagers = map[string]PackageManagerInfo{
 	// Node
-	"npm":  {Name: "NPM", Executable: "npm", LockFiles: []string{"package-lock.json"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init", "-y"}, InstallCmd: "install", InstallCmdWithoutArgs: "install", ExecutionCmd: "npx", UninstallCmd: "uninstall", SearchAPISupport: true, InstallationHint: "Install Node.js and npm from https://nodejs.org/"},
-	"pnpm": {Name: "PNPM", Executable: "pnpm", LockFiles: []string{"pnpm-lock.yaml"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ExecutionCmd: "dlx", UninstallCmd: "remove", SearchAPISupport: true, InstallationHint: "Run: npm install -g pnpm"},
-	"yarn": {Name: "Yarn", Executable: "yarn", LockFiles: []string{"yarn.lock"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init", "-y"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ExecutionCmd: "dlx", UninstallCmd: "remove", SearchAPISupport: true, InstallationHint: "Run: npm install -g yarn"},
-	"bun":  {Name: "Bun", Executable: "bun", LockFiles: []string{"bun.lockb", "bun.lock"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init", "-y"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ExecutionCmd: "bunx", UninstallCmd: "remove", SearchAPISupport: true, InstallationHint: "Run: curl -fsSL https://bun.sh/install | bash"},
+	"npm":  {Name: "NPM", Executable: "npm", LockFiles: []string{"package-lock.json"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init", "-y"}, InstallCmd: "install", InstallCmdWithoutArgs: "install", ExecutionCmd: "npx", FrozenCmd: "ci", DryRunFlag: "--dry-run", ManifestOnlyFlag: "--package-lock-only", IgnoreScriptsFlag: "--ignore-scripts", PreferOfflineFlag: "--prefer-offline", NoLockfileFlag: "--no-package-lock", ExactFlag: "--save-exact", UninstallCmd: "uninstall", DedupeCmd: "dedupe", PruneCmd: "prune", FreezeCmd: "ls --json", ListCmd: "ls", TreeCmd: "ls --all", DepthFlag: "--depth=", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Install Node.js and npm from https://nodejs.org/"},
+	"pnpm": {Name: "PNPM", Executable: "pnpm", LockFiles: []string{"pnpm-lock.yaml"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ExecutionCmd: "dlx", FrozenCmd: "install --frozen-lockfile", ManifestOnlyFlag: "--lockfile-only", IgnoreScriptsFlag: "--ignore-scripts", PreferOfflineFlag: "--prefer-offline", NoLockfileFlag: "--lockfile=false", ExactFlag: "--save-exact", UninstallCmd: "remove", DedupeCmd: "dedupe", PruneCmd: "prune", FreezeCmd: "list --json", ListCmd: "list", TreeCmd: "list --depth=Infinity", DepthFlag: "--depth=", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Run: npm install -g pnpm"},
+	"yarn": {Name: "Yarn", Executable: "yarn", LockFiles: []string{"yarn.lock"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init", "-y"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ExecutionCmd: "dlx", FrozenCmd: "install --immutable", ManifestOnlyFlag: "--mode=update-lockfile", IgnoreScriptsFlag: "--mode=skip-build", ExactFlag: "--exact", UninstallCmd: "remove", DedupeCmd: "dedupe", FreezeCmd: "list --json", ListCmd: "info --name-only", TreeCmd: "info --recursive --name-only", SearchAPISupport: true, InstallationHint: "Run: npm install -g yarn"},
+	"bun":  {Name: "Bun", Executable: "bun", LockFiles: []string{"bun.lock", "bun.lockb"}, MetadataFiles: []string{"package.json"}, InitArgs: []string{"init", "-y"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ExecutionCmd: "bunx", FrozenCmd: "install --frozen-lockfile", DryRunFlag: "--dry-run", IgnoreScriptsFlag: "--ignore-scripts", PreferOfflineFlag: "--prefer-offline", ExactFlag: "--exact", UninstallCmd: "remove", FreezeCmd: "pm ls", ListCmd: "pm ls", TreeCmd: "pm ls --all", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Run: curl -fsSL https://bun.sh/install | bash"},
+	// Deno
+	"deno": {Name: "Deno", Executable: "deno", LockFiles: []string{"deno.lock"}, MetadataFiles: []string{"deno.json", "deno.jsonc"}, InitArgs: []string{"init"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", UninstallCmd: "remove", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Install Deno from https://deno.com/"},
 	// Cocoapods
-	"pod": {Name: "CocoaPods", Executable: "pod", LockFiles: []string{"Podfile.lock"}, MetadataFiles: []string{"Podfile"}, InitArgs: []string{"init"}, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "", SearchAPISupport: true, InstallationHint: "Run: sudo gem install cocoapods"},
+	"pod": {Name: "CocoaPods", Executable: "pod", LockFiles: []string{"Podfile.lock"}, MetadataFiles: []string{"Podfile"}, InitArgs: []string{"init"}, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Run: sudo gem install cocoapods"},
+	// Swift
+	"swift": {Name: "Swift", Executable: "swift", LockFiles: []string{"Package.resolved"}, MetadataFiles: []string{"Package.swift"}, InitArgs: []string{"package", "init"}, InstallCmd: "package add-dependency", InstallCmdWithoutArgs: "package resolve", UninstallCmd: "", FreezeCmd: "package show-dependencies --format json", TreeCmd: "package show-dependencies", SearchAPISupport: true, InstallationHint: "Install Swift from https://www.swift.org/install/", ManualInstallHint: "Toolchains before Swift 5.9 can't add dependencies; add a .package(url:from:) entry to Package.swift, then run 'uni install'."},
+	// Ruby
+	"gem":    {Name: "RubyGems", Executable: "gem", LockFiles: nil, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", PrereleaseFlag: "--prerelease", UninstallCmd: "uninstall", FreezeCmd: "list", ListCmd: "list", WhichCmd: "info", OutdatedCmd: "outdated", SearchAPISupport: false, InstallationHint: "Install Ruby from https://www.ruby-lang.org/"},
+	"bundle": {Name: "Bundler", Executable: "bundle", LockFiles: []string{"Gemfile.lock"}, MetadataFiles: []string{"Gemfile"}, InitArgs: []string{"init"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", UninstallCmd: "remove", FreezeCmd: "list", ListCmd: "list", WhichCmd: "info", OutdatedCmd: "outdated", SearchAPISupport: false, InstallationHint: "Run: gem install bundler"},
 	// System Package Managers
-	"brew": {Name: "Homebrew", Executable: "brew", LockFiles: []string{}, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "uninstall", SearchAPISupport: true, InstallationHint: "Install Homebrew from https://brew.sh/"},
-	"pkgx": {Name: "pkgx", Executable: "pkgx", LockFiles: []string{"pkgx.yaml"}, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", ExecutionCmd: "pkgx", UninstallCmd: "uninstall", SearchAPISupport: false, InstallationHint: "Run: curl -fsS https://pkgx.sh | sh"},
+	"brew": {Name: "Homebrew", Executable: "brew", LockFiles: []string{}, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "uninstall", ReinstallCmd: "reinstall", FreezeCmd: "bundle dump --file=-", ListCmd: "list", TreeCmd: "deps --tree --installed", WhichCmd: "--prefix", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Install Homebrew from https://brew.sh/"},
+	"pkgx": {Name: "pkgx", Executable: "pkgx", LockFiles: []string{"pkgx.yaml"}, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", ExecutionCmd: "pkgx", UninstallCmd: "uninstall", SearchAPISupport: true, InstallationHint: "Run: curl -fsS https://pkgx.sh | sh"},
+	// Linux desktop apps; without project files these are only used when
+	// requested with --pkg.
+	"snap":    {Name: "Snap", Executable: "snap", LockFiles: nil, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "remove", FreezeCmd: "list", ListCmd: "list", WhichCmd: "info", OutdatedCmd: "refresh --list", SearchAPISupport: true, InstallationHint: "Install snapd from https://snapcraft.io/docs/installing-snapd"},
+	"flatpak": {Name: "Flatpak", Executable: "flatpak", LockFiles: nil, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "uninstall", FreezeCmd: "list --app", ListCmd: "list --app", WhichCmd: "info", OutdatedCmd: "remote-ls --updates", SearchAPISupport: true, InstallationHint: "Install Flatpak from https://flatpak.org/setup/"},
 	// Python
-	"pip":  {Name: "Pip", Executable: "pip", LockFiles: []string{"requirements.txt", "Pipfile.lock"}, MetadataFiles: []string{"setup.py", "pyproject.toml", "Pipfile"}, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "uninstall", SearchAPISupport: false, InstallationHint: "Install Python and pip from https://www.python.org/"},
-	"pipx": {Name: "Pipx", Executable: "pipx", LockFiles: []string{"pipx.json"}, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", UninstallCmd: "uninstall", SearchAPISupport: false, InstallationHint: "Run: pip install --user pipx && python -m pipx ensurepath"},
-	"uv":   {Name: "uv", Executable: "uv", LockFiles: []string{"uv.lock", "pylock.toml"}, MetadataFiles: []string{"pyproject.toml", "requirements.txt", "Pipfile"}, InitArgs: []string{"init"}, InstallCmd: "add", InstallCmdWithoutArgs: "", UninstallCmd: "remove", SearchAPISupport: false, InstallationHint: "Install uv from https://docs.astral.sh/uv"},
+	"pip":    {Name: "Pip", Executable: "pip", LockFiles: []string{"requirements.txt", "Pipfile.lock"}, MetadataFiles: []string{"setup.py", "pyproject.toml", "Pipfile"}, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "install -r requirements.txt", PrereleaseFlag: "--pre", UninstallCmd: "uninstall", ReinstallCmd: "install --force-reinstall", FreezeCmd: "freeze", ListCmd: "list", WhichCmd: "show", OutdatedCmd: "list --outdated", SearchAPISupport: false, InstallationHint: "Install Python and pip from https://www.python.org/"},
+	"pipx":   {Name: "Pipx", Executable: "pipx", LockFiles: []string{"pipx.json"}, MetadataFiles: nil, InitArgs: nil, InstallCmd: "install", InstallCmdWithoutArgs: "", PrereleaseFlag: "--pip-args=--pre", UninstallCmd: "uninstall", ReinstallCmd: "reinstall", FreezeCmd: "list --json", ListCmd: "list", SearchAPISupport: false, InstallationHint: "Run: pip install --user pipx && python -m pipx ensurepath"},
+	"uv":     {Name: "uv", Executable: "uv", LockFiles: []string{"uv.lock", "pylock.toml"}, MetadataFiles: []string{"pyproject.toml", "requirements.txt", "Pipfile"}, InitArgs: []string{"init"}, InstallCmd: "add", InstallCmdWithoutArgs: "", ManifestOnlyFlag: "--no-sync", PrereleaseFlag: "--prerelease=allow", UninstallCmd: "remove", FreezeCmd: "pip freeze", ListCmd: "pip list", TreeCmd: "tree", DepthFlag: "--depth=", WhichCmd: "pip show", OutdatedCmd: "pip list --outdated", SearchAPISupport: false, InstallationHint: "Install uv from https://docs.astral.sh/uv"},
+	"poetry": {Name: "Poetry", Executable: "poetry", LockFiles: []string{"poetry.lock"}, MetadataFiles: nil, InitArgs: []string{"init", "--no-interaction"}, InstallCmd: "add", InstallCmdWithoutArgs: "install", ManifestOnlyFlag: "--lock", PrereleaseFlag: "--allow-prereleases", UninstallCmd: "remove", FreezeCmd: "show", ListCmd: "show", TreeCmd: "show --tree", WhichCmd: "show", OutdatedCmd: "show --outdated", SearchAPISupport: false, InstallationHint: "Install Poetry from https://python-poetry.org/docs/#installation"},
+	// Elixir
+	"mix": {Name: "Mix", Executable: "mix", LockFiles: []string{"mix.lock"}, MetadataFiles: []string{"mix.exs"}, InitArgs: nil, InstallCmd: "", InstallCmdWithoutArgs: "deps.get", UninstallCmd: "", PruneCmd: "deps.clean --unlock --unused", ListCmd: "deps", TreeCmd: "deps.tree", OutdatedCmd: "hex.outdated", SearchAPISupport: true, InstallationHint: "Install Elixir from https://elixir-lang.org/install.html", ManualInstallHint: "Add {:name, \"~> version\"} to deps in mix.exs, then run 'uni install' to fetch it."},
+	// Haskell
+	"cabal": {Name: "Cabal", Executable: "cabal", LockFiles: []string{"cabal.project.freeze"}, MetadataFiles: []string{"cabal.project"}, InitArgs: []string{"init", "--non-interactive"}, InstallCmd: "", InstallCmdWithoutArgs: "build --only-dependencies", UninstallCmd: "", FreezeCmd: "freeze", OutdatedCmd: "outdated", SearchAPISupport: true, InstallationHint: "Install GHCup from https://www.haskell.org/ghcup/", ManualInstallHint: "Add the package to build-depends in your .cabal file, then run 'uni install'."},
+	"stack": {Name: "Stack", Executable: "stack", LockFiles: []string{"stack.yaml.lock"}, MetadataFiles: []string{"stack.yaml"}, InitArgs: []string{"init"}, InstallCmd: "", InstallCmdWithoutArgs: "build --only-dependencies", UninstallCmd: "", ListCmd: "ls dependencies", TreeCmd: "ls dependencies tree", DepthFlag: "--depth=", SearchAPISupport: true, InstallationHint: "Install GHCup from https://www.haskell.org/ghcup/", ManualInstallHint: "Add the package to dependencies in package.yaml (or build-depends in the .cabal file), then run 'uni install'."},
+	// C/C++
+	"vcpkg": {Name: "vcpkg", Executable: "vcpkg", LockFiles: nil, MetadataFiles: []string{"vcpkg.json"}, InitArgs: []string{"new", "--application"}, InstallCmd: "add port", InstallCmdWithoutArgs: "install", UninstallCmd: "", ListCmd: "list", SearchAPISupport: true, InstallationHint: "Install vcpkg from https://learn.microsoft.com/vcpkg/get_started/get-started"},
+	"conan": {Name: "Conan", Executable: "conan", LockFiles: []string{"conan.lock"}, MetadataFiles: []string{"conanfile.txt", "conanfile.py"}, InitArgs: nil, InstallCmd: "", InstallCmdWithoutArgs: "install . --build=missing", UninstallCmd: "", TreeCmd: "graph info .", SearchAPISupport: true, InstallationHint: "Run: pip install conan", ManualInstallHint: "Add the reference, e.g. zlib/1.3.1, under [requires] in conanfile.txt (or to requires in conanfile.py), then run 'uni install'."},
 	// Go
-	"go": {Name: "Go", Executable: "go", LockFiles: []string{"go.sum"}, MetadataFiles: []string{"go.mod"}, InitArgs: nil, InstallCmd: "get", InstallCmdWithoutArgs: "", UninstallCmd: "get -u", SearchAPISupport: false, InstallationHint: "Install Go from https://golang.org/dl/"},
+	"go": {Name: "Go", Executable: "go", LockFiles: []string{"go.sum"}, MetadataFiles: []string{"go.mod"}, InitArgs: nil, InstallCmd: "get", InstallCmdWithoutArgs: "", UninstallCmd: "get -u", PruneCmd: "mod tidy", FreezeCmd: "list -m all", ListCmd: "list -m all", TreeCmd: "mod graph", WhichCmd: "list -m", OutdatedCmd: "list -m -u all", SearchAPISupport: true, InstallationHint: "Install Go from https://golang.org/dl/", VersionCmd: "version"},
 }
 
 const uniConfigFile = ".unirc"
 
-var httpClient = &http.Client{Timeout: 10 * time.Second}
+// detectionOrder is the order detectPackageManager considers managers in, so
+// the result doesn't depend on map iteration when several project files
+// exist. Shared manifests resolve to the first entry, e.g. package.json to npm.
+// Deno comes first so a Deno project that also has a package.json stays Deno.
+var detectionOrder = []string{"deno", "npm", "pnpm", "yarn", "bun", "pod", "swift", "bundle", "gem", "pip", "uv", "poetry", "pipx", "mix", "stack", "cabal", "vcpkg", "conan", "go", "pkgx", "brew", "snap", "flatpak"}
+
+// managerAliases maps ecosystem names accepted by --pkg and UNI_PKG to the
+// managers they choose between. The first entry is used when no project file
+// points at another one.
+var managerAliases = map[string][]string{
+	"node":   {"npm", "pnpm", "yarn", "bun"},
+	"python": {"pip", "uv", "poetry", "pipx"},
+	"ruby":   {"gem", "bundle"},
+}
+
+const defaultNPMRegistry = "https://registry.npmjs.org"
+
+var httpClient = newHTTPClient()
+
+// newHTTPClient builds the client used for registry searches. Proxies are
+// read from HTTP_PROXY, HTTPS_PROXY and NO_PROXY; UNI_INSECURE_SKIP_VERIFY
+// disables TLS verification for corporate proxies with self-signed certs.
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if insecureSkipVerify() {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
+
+// searchRetries is how many times getWithRetry retries a rate-limited or
+// failing registry before giving up.
+const searchRetries = 3
+
+// getWithRetry sends a GET request, retrying 429 and 5xx responses with
+// exponential backoff. A Retry-After header, when present and reasonable,
+// replaces the computed delay.
+func getWithRetry(rawURL string) (*http.Response, error) {
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		resp, err := httpClient.Get(rawURL)
+		if err != nil || attempt == searchRetries || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) {
+			return resp, err
+		}
+		resp.Body.Close()
+		wait := delay
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 && seconds <= 30 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			logVerbose("%s: rate limited, retrying in %s", resp.Request.URL.Host, wait)
+		} else {
+			logVerbose("%s: %s, retrying in %s", resp.Request.URL.Host, resp.Status, wait)
+		}
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
+
+func insecureSkipVerify() bool {
+	skip, _ := strconv.ParseBool(os.Getenv("UNI_INSECURE_SKIP_VERIFY"))
+	return skip
+}
+
+// workDir is the directory used for project detection and child processes.
+// It is empty (the current directory) unless overridden with --cwd.
+var workDir string
+
+// detectFrom forces detection to the manager owning this lockfile name, set
+// with --detect-from.
+var detectFrom string
+
+// offline stops searches from using the network, set with --offline or
+// UNI_OFFLINE.
+var offline, _ = strconv.ParseBool(os.Getenv("UNI_OFFLINE"))
+
+// jobs bounds how many searches and child processes run in parallel, set
+// with --jobs.
+var jobs = runtime.NumCPU()
+
+// jobSlots is the semaphore shared by all parallel work, sized from jobs on
+// first use.
+var jobSlots = sync.OnceValue(func() chan struct{} {
+	return make(chan struct{}, jobs)
+})
+
+func acquireJob() { jobSlots() <- struct{}{} }
+
+func releaseJob() { <-jobSlots() }
+
+// fetchDetails calls fetch for every item in parallel, bounded by the shared
+// job slots, for work such as searches that list names first and then look
+// each one up. Results and errors are returned in the order of items; a
+// failed call leaves the zero value at its index.
+func fetchDetails[K, T any](items []K, fetch func(item K) (T, error)) ([]T, []error) {
+	results := make([]T, len(items))
+	errs := make([]error, len(items))
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acquireJob()
+			defer releaseJob()
+			results[i], errs[i] = fetch(item)
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// autoInstallManager installs a missing manager from its installation hint
+// without asking, set with --auto-install-manager.
+var autoInstallManager bool
+
+// verbose enables diagnostic output on stderr, set with --verbose.
+var verbose bool
+
+// logVerbose prints a diagnostic message when --verbose is set.
+func logVerbose(format string, a ...any) {
+	if verbose {
+		fmt.Fprintln(os.Stderr, color.HiBlackString("[verbose] "+format, a...))
+	}
+}
+
+// strict makes a configured manager that isn't installed an error instead
+// of falling back to detection, set with --strict.
+var strict bool
+
+// jsonOutput switches output, including errors, to JSON, set with --json.
+var jsonOutput bool
+
+// dryRun prints manager commands instead of running them, set with --dry-run.
+var dryRun bool
+
+// extraEnv holds KEY=VAL pairs from --env, added to the environment of
+// every manager command.
+var extraEnv []string
+
+// cmdTimeout bounds how long a manager command may run, set with
+// --cmd-timeout. Zero means no limit.
+var cmdTimeout time.Duration
 
 func main() {
+	loadUserManagers()
 	args := os.Args[1:]
 	if len(args) == 0 {
 		printHelp()
 		return
 	}
-	var specifiedManager string
-	if len(args) > 0 && strings.HasPrefix(args[0], "--pkg=") {
-		specifiedManager = strings.TrimPrefix(args[0], "--pkg=")
+	specifiedManager := os.Getenv("UNI_PKG")
+globalFlags:
+	for len(args) > 0 {
+		switch {
+		case strings.HasPrefix(args[0], "--pkg="):
+			specifiedManager = strings.TrimPrefix(args[0], "--pkg=")
+			pkgSource = "--pkg"
+		case strings.HasPrefix(args[0], "--cmd-timeout="):
+			timeout, err := time.ParseDuration(strings.TrimPrefix(args[0], "--cmd-timeout="))
+			if err != nil || timeout <= 0 {
+				exitWithError(newError(ErrUsage, "--cmd-timeout expects a positive duration such as 90s or 5m"))
+			}
+			cmdTimeout = timeout
+		case strings.HasPrefix(args[0], "--detect-from="):
+			detectFrom = filepath.Base(strings.TrimPrefix(args[0], "--detect-from="))
+		case args[0] == "--dry-run":
+			dryRun = true
+		case args[0] == "--json":
+			jsonOutput = true
+		case args[0] == "--no-color":
+			color.NoColor = true
+		case strings.HasPrefix(args[0], "--color="):
+			switch mode := strings.TrimPrefix(args[0], "--color="); mode {
+			case "always":
+				color.NoColor = false
+			case "never":
+				color.NoColor = true
+			case "auto":
+				color.NoColor = os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" ||
+					!(isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()))
+			default:
+				exitWithError(newError(ErrUsage, "--color expects always, never or auto, got '%s'", mode))
+			}
+		case strings.HasPrefix(args[0], "--config="):
+			configPath = strings.TrimPrefix(args[0], "--config=")
+		case strings.HasPrefix(args[0], "--jobs="):
+			n, err := strconv.Atoi(strings.TrimPrefix(args[0], "--jobs="))
+			if err != nil || n < 1 {
+				exitWithError(newError(ErrUsage, "--jobs expects a number of at least 1"))
+			}
+			jobs = n
+		case args[0] == "--auto-install-manager":
+			autoInstallManager = true
+		case args[0] == "--verbose":
+			verbose = true
+		case args[0] == "--strict":
+			strict = true
+		case args[0] == "--check-engines":
+			checkEngines = true
+		case args[0] == "--offline":
+			offline = true
+		case strings.HasPrefix(args[0], "--cwd="):
+			if err := setWorkDir(strings.TrimPrefix(args[0], "--cwd=")); err != nil {
+				exitWithError(&uniError{Category: ErrUsage, Err: err})
+			}
+		case strings.HasPrefix(args[0], "--manifest="):
+			if err := setManifest(strings.TrimPrefix(args[0], "--manifest=")); err != nil {
+				exitWithError(&uniError{Category: ErrUsage, Err: err})
+			}
+		case strings.HasPrefix(args[0], "--venv="):
+			venvDir = strings.TrimPrefix(args[0], "--venv=")
+		case args[0] == "--env" || strings.HasPrefix(args[0], "--env="):
+			value, ok := strings.CutPrefix(args[0], "--env=")
+			if !ok {
+				if len(args) < 2 {
+					exitWithError(usageError("uni --env KEY=VAL <command> [args...]"))
+				}
+				args = args[1:]
+				value = args[0]
+			}
+			if key, _, ok := strings.Cut(value, "="); !ok || key == "" || strings.ContainsAny(key, " \t") {
+				exitWithError(newError(ErrUsage, "--env expects KEY=VAL, got '%s'", value))
+			}
+			extraEnv = append(extraEnv, value)
+		default:
+			break globalFlags
+		}
 		args = args[1:]
 	}
+	if jsonOutput {
+		// Keep stdout clean for JSON; progress messages go to stderr.
+		color.Output = color.Error
+	}
 	if len(args) > 0 {
 		command := args[0]
 		commandArgs := args[1:]
 		switch command {
 		case "init":
+			var check bool
+			commandArgs, check = takeBoolFlag(commandArgs, "--check")
 			if len(commandArgs) != 1 {
-				color.Red("Usage: uni init <package_manager>")
-				os.Exit(1)
+				exitWithError(usageError("uni init [--check] <package_manager>"))
+			}
+			if check {
+				handleInitCheck(commandArgs[0])
+				return
 			}
 			handleInit(commandArgs[0])
 			return
 		case "search", "s":
-			if len(commandArgs) == 0 {
-				color.Red("Usage: uni search <query>")
-				os.Exit(1)
+			opts, query, err := parseSearchArgs(commandArgs)
+			if err != nil {
+				exitWithError(&uniError{Category: ErrUsage, Err: err})
 			}
-			manager, _ := detectPackageManager(specifiedManager)
-			handleApiSearch(manager, strings.Join(commandArgs, " "))
-			return
-		case "x", "exec":
-			if len(commandArgs) == 0 {
-				color.Red("Usage: uni x <command> [args...]")
-				os.Exit(1)
+			if query == "" {
+				exitWithError(usageError("uni search <query> [--open[=N]]"))
+			}
+			recordStats(func(stats *usageStats) { stats.Searches[strings.ToLower(query)]++ })
+			if opts.JSONLines {
+				// NDJSON shares --json's clean stdout and JSON errors.
+				jsonOutput = true
+				color.Output = color.Error
+			}
+			if specifiedManager == "all" {
+				if err := handleSearchAll(query, opts); err != nil {
+					exitWithError(err)
+				}
+				return
+			}
+			if opts.DedupeResults {
+				color.Yellow("--dedupe-results only applies to --pkg=all searches, ignoring it.")
 			}
 			manager, _ := detectPackageManager(specifiedManager)
-			var cmd *exec.Cmd
-			switch manager.Name {
-			case "PNPM", "Yarn":
-				color.Cyan("▶️  Executing command: %s %s %s", manager.Executable, manager.ExecutionCmd, strings.Join(commandArgs, " "))
-				cmd = exec.Command(manager.Executable, append([]string{manager.ExecutionCmd}, commandArgs...)...)
+			if err := handleApiSearch(manager, query, opts); err != nil {
+				exitWithError(fmt.Errorf("search failed: %w", err))
+			}
+			return
+		case "config":
+			const configUsage = "uni config validate [file] | get <key> | set <key> <value...>"
+			switch {
+			case len(commandArgs) == 2 && commandArgs[0] == "get":
+				handleConfigGet(commandArgs[1])
+			case len(commandArgs) >= 3 && commandArgs[0] == "set":
+				handleConfigSet(commandArgs[1], commandArgs[2:])
+			case len(commandArgs) >= 1 && len(commandArgs) <= 2 && commandArgs[0] == "validate":
+				path := projectConfigPath()
+				if len(commandArgs) == 2 {
+					path = commandArgs[1]
+				}
+				handleConfigValidate(path)
 			default:
-				color.Cyan("▶️  Executing command: %s %s", manager.ExecutionCmd, strings.Join(commandArgs, " "))
-				cmd = exec.Command(manager.ExecutionCmd, commandArgs...)
+				exitWithError(usageError(configUsage))
+			}
+			return
+		case "help":
+			handleHelp(commandArgs)
+			return
+		case "stats":
+			rest, asJSON := takeBoolFlag(commandArgs, "--json")
+			if len(rest) != 0 {
+				exitWithError(usageError("uni stats [--json]"))
+			}
+			handleStats(asJSON || jsonOutput)
+			return
+		case "cache":
+			if len(commandArgs) != 1 || commandArgs[0] != "verify" {
+				exitWithError(usageError("uni cache verify"))
+			}
+			handleCacheVerify()
+			return
+		case "doctor":
+			handleDoctor()
+			return
+		case "self-update":
+			rest, checkOnly := takeBoolFlag(commandArgs, "--check-only")
+			if len(rest) != 0 {
+				exitWithError(usageError("uni self-update [--check-only]"))
+			}
+			handleSelfUpdate(checkOnly)
+			return
+		case "detect":
+			rest, asJSON := takeBoolFlag(commandArgs, "--json")
+			if len(rest) != 0 {
+				exitWithError(usageError("uni detect [--json]"))
+			}
+			jsonOutput = jsonOutput || asJSON
+			handleDetect(specifiedManager)
+			return
+		case "managers":
+			rest, asJSON := takeBoolFlag(commandArgs, "--json")
+			if len(rest) != 0 {
+				exitWithError(usageError("uni managers [--json]"))
+			}
+			handleManagers(asJSON || jsonOutput)
+			return
+		case "bundle":
+			rest, retryFailed := takeBoolFlag(commandArgs, "--retry-failed")
+			if len(rest) != 1 {
+				exitWithError(usageError("uni bundle [--retry-failed] <file>"))
 			}
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			cmd.Stdin = os.Stdin
-			if err := cmd.Run(); err != nil {
-				color.Red("Error executing command: %v", err)
-				os.Exit(1)
+			manager, err := detectPackageManager(specifiedManager)
+			if err != nil {
+				exitWithError(err)
 			}
+			handleBundle(manager, rest[0], retryFailed)
+			return
+		case "migrate":
+			if len(commandArgs) != 1 {
+				exitWithError(usageError("uni migrate <package_manager>"))
+			}
+			manager, err := detectPackageManager(specifiedManager)
+			if err != nil {
+				exitWithError(err)
+			}
+			if err := handleMigrate(manager, commandArgs[0]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "rollback":
+			if len(commandArgs) != 0 {
+				exitWithError(usageError("uni rollback"))
+			}
+			manager, err := detectPackageManager(specifiedManager)
+			if err != nil {
+				exitWithError(err)
+			}
+			if err := handleRollback(manager); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "outdated":
+			if rest, all := takeBoolFlag(commandArgs, "--all-managers"); all {
+				if err := handleOutdatedAll(rest); err != nil {
+					exitWithError(err)
+				}
+				return
+			}
+		case "x", "exec":
+			if len(commandArgs) == 0 {
+				exitWithError(usageError("uni x [--no-cache] [--version=<ver>] <command> [args...]"))
+			}
+			handleExec(specifiedManager, commandArgs)
 			return
 		}
 	}
 	manager, err := detectPackageManager(specifiedManager)
 	if err != nil {
-		color.Red("Error: %v", err)
-		os.Exit(1)
+		exitWithError(err)
 	}
 	color.Cyan("▶️  Using %s...", manager.Name)
 	executeCliCommand(manager, args)
 }
 
-func handleApiSearch(pm PackageManagerInfo, query string) {
+func handleApiSearch(pm PackageManagerInfo, query string, opts searchOptions) error {
 	if !pm.SearchAPISupport {
 		color.Yellow("%s does not support API search. Falling back to CLI.", pm.Name)
 		executeCliCommand(pm, []string{"search", query})
-		return
+		return nil
+	}
+	if offline && searchNeedsNetwork(pm) {
+		return &uniError{
+			Category: ErrNetwork,
+			Err:      fmt.Errorf("%s search needs the network, which is disabled in offline mode", pm.Name),
+			Hint:     "Drop --offline or unset UNI_OFFLINE to search online.",
+		}
 	}
 
 	color.Cyan("🔍 Searching for '%s' using %s...", query, pm.Name)
+	if insecureSkipVerify() {
+		color.Yellow("Warning: TLS certificate verification is disabled by UNI_INSECURE_SKIP_VERIFY.")
+	}
 
-	var err error
-	switch pm.Name {
-	case "NPM", "PNPM", "Yarn", "Bun":
-		err = searchNPM(query)
-	case "Homebrew":
-		// New: Use the local CLI JSON method for Homebrew
-		err = searchHomebrewCliJson(query)
-	case "CocoaPods":
-		err = searchCocoaPods(query)
-	default:
-		color.Red("API search not implemented for %s.", pm.Name)
+	if opts.Since > 0 && !slices.Contains([]string{"NPM", "PNPM", "Yarn", "Bun"}, pm.Name) {
+		color.Yellow("--since is only supported for npm registry searches, ignoring it for %s.", pm.Name)
+	}
+	if opts.Tap != "" && pm.Name != "Homebrew" {
+		color.Yellow("--tap only applies to Homebrew, ignoring it for %s.", pm.Name)
+	}
+	if opts.MinScore > 0 && !slices.Contains([]string{"NPM", "PNPM", "Yarn", "Bun"}, pm.Name) {
+		color.Yellow("--min-score is only supported for npm registry searches, ignoring it for %s.", pm.Name)
+	}
+	if opts.Registry != "" && !slices.Contains([]string{"NPM", "PNPM", "Yarn", "Bun"}, pm.Name) {
+		color.Yellow("--registry only applies to npm registry searches, ignoring it for %s.", pm.Name)
 	}
 
+	spin := startSpinner("Waiting for " + pm.Name + "...")
+	results, total, err := fetchSearchResults(pm, query, opts)
+	spin.stop()
 	if err != nil {
-		color.Red("Search failed: %v", err)
+		return err
 	}
-}
 
-func searchHomebrewCliJson(query string) error {
-	searchCmd := exec.Command("brew", "search", query)
-	var searchOut bytes.Buffer
-	searchCmd.Stdout = &searchOut
-	if err := searchCmd.Run(); err != nil {
-		color.Yellow("No results found for '%s' in Homebrew search.", query)
+	out, finish, err := searchOutput(opts.Output)
+	if err != nil {
+		return err
 	}
-
-	scanner := bufio.NewScanner(&searchOut)
-	var resultsFound bool
-	for scanner.Scan() {
-		line := scanner.Text()
-		// `brew search` can have headers or empty lines, we ignore them.
-		if strings.HasPrefix(line, "==>") || line == "" {
-			continue
+	if opts.JSONLines {
+		if err := writeJSONLines(out, results); err != nil {
+			return err
 		}
-		pkgName := strings.Fields(line)[0] // Get the first word of the line
-
-		infoCmd := exec.Command("brew", "info", "--json=v2", pkgName)
-		var infoOut bytes.Buffer
-		infoCmd.Stdout = &infoOut
-		if err := infoCmd.Run(); err != nil {
-			continue
+		return finish()
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if results == nil {
+			results = []PackageResult{}
 		}
-
-		var results BrewCliInfoResponse
-		if err := json.Unmarshal(infoOut.Bytes(), &results); err != nil {
-			continue // Skip if JSON is unparsable
+		if err := encoder.Encode(results); err != nil {
+			return err
 		}
+		return finish()
+	}
+	for i, result := range results {
+		printPackageInfo(out, i+1, result, query, opts.MaxDesc)
+	}
+	if len(results) > 0 {
+		fmt.Fprintln(out, color.YellowString("---"))
+		fmt.Fprintf(out, "Showing %d of %d results.\n", len(results), max(total, len(results)))
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+	if opts.Output != "" {
+		return nil
+	}
+	if opts.Open > 0 {
+		openSearchResult(results, opts.Open)
+		return nil
+	}
+	if len(results) > 0 && interactive() {
+		return promptInstall(pm, results)
+	}
+	return nil
+}
 
-		for _, item := range results.Formulae {
-			resultsFound = true
-			printPackageInfo(map[string]string{
-				"Name":        item.Name,
-				"Description": item.Desc,
-				"License":     item.License,
-				"Type":        "Formula",
-				"Homepage":    item.Homepage,
-			})
+// searchNeedsNetwork reports whether pm's search goes over the network.
+// Homebrew searches its local tap checkout, Flatpak its cached appstream
+// data and vcpkg the ports in its registry checkout.
+func searchNeedsNetwork(pm PackageManagerInfo) bool {
+	return pm.Name != "Homebrew" && pm.Name != "Flatpak" && pm.Name != "vcpkg"
+}
+
+// fetchSearchResults runs pm's search and applies the filters in opts.
+// The search itself holds a job slot, released before per-result lookups
+// such as --latest-only, which take slots of their own through fetchDetails;
+// holding both would deadlock once every slot waits on a nested lookup.
+func fetchSearchResults(pm PackageManagerInfo, query string, opts searchOptions) ([]PackageResult, int, error) {
+	var results []PackageResult
+	var total int
+	var err error
+	// Homebrew and Go take job slots for each process or request they make.
+	if pm.Name == "Homebrew" || pm.Name == "Go" {
+		results, total, err = searchManager(pm, query, opts)
+	} else {
+		acquireJob()
+		results, total, err = searchManager(pm, query, opts)
+		releaseJob()
+	}
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, 0, &uniError{Category: ErrNetwork, Err: err}
 		}
-		for _, item := range results.Casks {
-			resultsFound = true
-			printPackageInfo(map[string]string{
-				"Name":        item.Token,
-				"Description": item.Desc,
-				"Type":        "Cask",
-				"Homepage":    item.Homepage,
-			})
+		return nil, 0, err
+	}
+	if opts.Exact {
+		results = slices.DeleteFunc(results, func(result PackageResult) bool {
+			return !strings.EqualFold(result.Name, query)
+		})
+		if len(results) == 0 {
+			color.Yellow("No package is named exactly '%s'.", query)
 		}
+		total = len(results)
+	}
+	npmFamily := slices.Contains([]string{"NPM", "PNPM", "Yarn", "Bun"}, pm.Name)
+	if (opts.LatestOnly || opts.IncludePrerelease && npmFamily) && len(results) > 0 {
+		resolveLatestVersions(pm, results, opts)
 	}
+	for i := range results {
+		results[i].Registry = pm.Name
+	}
+	return results, total, nil
+}
 
-	if !resultsFound {
-		color.Yellow("No formulae or casks found.")
+// searchManager runs pm's own search, before the filters in opts apply.
+func searchManager(pm PackageManagerInfo, query string, opts searchOptions) ([]PackageResult, int, error) {
+	switch pm.Name {
+	case "NPM", "PNPM", "Yarn", "Bun":
+		return searchNPM(query, opts)
+	case "Homebrew":
+		// New: Use the local CLI JSON method for Homebrew
+		return searchHomebrewCliJson(query, opts.Tap, opts.BrewType)
+	case "CocoaPods":
+		return searchCocoaPods(query)
+	case "Go":
+		return searchGoPackages(query)
+	case "pkgx":
+		return searchPkgx(query)
+	case "Mix":
+		return searchHex(query, opts.IncludePrerelease)
+	case "Cabal", "Stack":
+		return searchHackage(query)
+	case "Swift":
+		return searchSwiftPackageIndex(query)
+	case "Deno":
+		return searchJSR(query)
+	case "Snap":
+		return searchSnap(query)
+	case "Flatpak":
+		return searchFlatpak(query)
+	case "vcpkg":
+		return searchVcpkg(query)
+	case "Conan":
+		return searchConan(query)
+	default:
+		return nil, 0, newError(ErrManagerUnsupported, "API search not implemented for %s", pm.Name)
 	}
+}
 
-	return nil
+// resolveLatestVersions replaces each result's version with the latest
+// release from the registry's per-package metadata, for search
+// --latest-only. Search indexes can lag behind publishes; registries not
+// handled here either report the current version in search already or
+// publish none. Pre-releases only count with --include-prerelease, which for
+// npm also looks past the latest tag to tags such as next. Lookups that fail
+// keep the search version.
+func resolveLatestVersions(pm PackageManagerInfo, results []PackageResult, opts searchOptions) {
+	var fetch func(result PackageResult) (string, error)
+	switch pm.Name {
+	case "NPM", "PNPM", "Yarn", "Bun":
+		registry, token := searchRegistry(opts)
+		fetch = func(result PackageResult) (string, error) {
+			tags, err := fetchNPMDistTags(registry, token, result.Name)
+			if err != nil || !opts.IncludePrerelease {
+				return tags["latest"], err
+			}
+			return newestVersion(slices.Collect(maps.Values(tags)), true), nil
+		}
+	case "CocoaPods":
+		fetch = func(result PackageResult) (string, error) {
+			return fetchCocoaPodsLatest(result.Name, opts.IncludePrerelease)
+		}
+	case "Cabal", "Stack":
+		fetch = func(result PackageResult) (string, error) { return fetchHackageLatest(result.Name) }
+	default:
+		color.Yellow("--latest-only has no version lookup for %s, keeping the versions its search reported.", pm.Name)
+		return
+	}
+	versions, errs := fetchDetails(results, fetch)
+	failed := 0
+	for i, version := range versions {
+		if errs[i] != nil || version == "" {
+			logVerbose("%s: latest version lookup failed: %v", results[i].Name, errs[i])
+			failed++
+			continue
+		}
+		results[i].Version = version
+	}
+	if failed > 0 {
+		color.Yellow("Could not look up the latest version of %d package(s); showing the search version.", failed)
+	}
 }
 
-func searchNPM(query string) error {
-	resp, err := httpClient.Get("https://registry.npmjs.org/-/v1/search?text=" + url.QueryEscape(query) + "&size=10")
+// fetchNPMDistTags returns the dist-tags, such as latest and next, from a
+// package's abbreviated registry document.
+func fetchNPMDistTags(registry, token, name string) (map[string]string, error) {
+	req, err := newNPMDocumentRequest(registry, token, name)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
-	var results NPMRegistrySearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return fmt.Errorf("could not parse NPM response: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry %s returned %s", registry, resp.Status)
 	}
-	if len(results.Objects) == 0 {
-		color.Yellow("No packages found.")
-		return nil
+	var doc struct {
+		DistTags map[string]string `json:"dist-tags"`
 	}
-	for _, item := range results.Objects {
-		pkg := item.Package
-		printPackageInfo(map[string]string{
-			"Name":        pkg.Name,
-			"Description": pkg.Description,
-			"Version":     pkg.Version,
-			"Homepage":    pkg.Links.Homepage,
-			"Author":      pkg.Author.Name,
-		})
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
 	}
-	return nil
+	return doc.DistTags, nil
 }
 
-func searchCocoaPods(query string) error {
-	resp, err := httpClient.Get("https://search.cocoapods.org/api/v1/pods.flat.hash.json?query=" + url.QueryEscape(query) + "&amount=10")
+// fetchCocoaPodsLatest returns the newest version pushed to CocoaPods trunk.
+func fetchCocoaPodsLatest(name string, includePrerelease bool) (string, error) {
+	resp, err := getWithRetry("https://trunk.cocoapods.org/api/v1/pods/" + url.PathEscape(name))
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
-	var results CocoaPodsAPISearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return fmt.Errorf("could not parse CocoaPods response: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("trunk.cocoapods.org returned %s", resp.Status)
 	}
-	if results.Total == 0 {
-		color.Yellow("No pods found.")
-		return nil
+	var pod struct {
+		Versions []struct {
+			Name string `json:"name"`
+		} `json:"versions"`
 	}
-	for _, item := range results.Results {
-		printPackageInfo(map[string]string{
-			"Name":        item.ID,
-			"Description": item.Summary,
-			"Version":     item.Version,
-			"Source":      item.Source.Git,
-		})
+	if err := json.NewDecoder(resp.Body).Decode(&pod); err != nil {
+		return "", err
 	}
-	return nil
-}
-
-func printPackageInfo(info map[string]string) {
-	fmt.Println(color.YellowString("---"))
-	keyColor := color.New(color.FgGreen)
-	for key, val := range info {
-		if val != "" {
-			keyColor.Printf("%-14s", key+":")
-			fmt.Printf("%s\n", val)
-		}
+	var versions []string
+	for _, v := range pod.Versions {
+		versions = append(versions, v.Name)
 	}
+	return newestVersion(versions, includePrerelease), nil
 }
 
-func executeCliCommand(pm PackageManagerInfo, args []string) {
-	if _, err := exec.LookPath(pm.Executable); err != nil {
-		color.Red("Error: %s (%s) is not installed or not in your PATH.", pm.Name, pm.Executable)
-		color.Yellow("Hint: %s", pm.InstallationHint)
-		os.Exit(1)
+// fetchHackageLatest returns the newest non-deprecated version of a Hackage
+// package.
+func fetchHackageLatest(name string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://hackage.haskell.org/package/"+url.PathEscape(name)+"/preferred", nil)
+	if err != nil {
+		return "", err
 	}
-	if len(args) > 0 {
-		switch args[0] {
-		case "install", "i", "add":
-			if len(args) == 1 && pm.InstallCmdWithoutArgs != "" {
-				args[0] = pm.InstallCmdWithoutArgs
-			} else if pm.InstallCmd == "" {
-				color.Red("%s does not have a standard install command.", pm.Name)
-				os.Exit(1)
-			} else {
-				args[0] = pm.InstallCmd
-			}
-		case "uninstall", "remove", "rm", "un":
-			if pm.UninstallCmd == "" {
-				color.Red("%s does not have a standard uninstall command.", pm.Name)
-				os.Exit(1)
-			}
-			args[0] = pm.UninstallCmd
-		}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
 	}
-	cmd := exec.Command(pm.Executable, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	color.HiBlack("+ %s %s", pm.Executable, strings.Join(args, " "))
-	if err := cmd.Run(); err != nil {
-		os.Exit(1)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Hackage returned %s", resp.Status)
+	}
+	var preferred struct {
+		Normal []string `json:"normal-version"`
 	}
+	if err := json.NewDecoder(resp.Body).Decode(&preferred); err != nil {
+		return "", err
+	}
+	// Hackage versions are purely numeric; there are no pre-releases.
+	return newestVersion(preferred.Normal, true), nil
 }
 
-func detectPackageManager(specifiedManager string) (PackageManagerInfo, error) {
-	if specifiedManager != "" {
-		if pm, ok := supportedManagers[specifiedManager]; ok {
-			return pm, nil
+// newestVersion returns the highest of versions, or "" for none. Versions
+// with a pre-release suffix such as -beta.1 are skipped unless
+// includePrerelease is set. Versions are visited in sorted order, so the
+// answer doesn't depend on how they were collected, e.g. from a map.
+func newestVersion(versions []string, includePrerelease bool) string {
+	newest := ""
+	for _, v := range slices.Sorted(slices.Values(versions)) {
+		if !includePrerelease && strings.Contains(v, "-") {
+			continue
 		}
-		return PackageManagerInfo{}, fmt.Errorf("specified package manager '%s' is not supported", specifiedManager)
-	}
-	if config, err := os.ReadFile(uniConfigFile); err == nil {
-		managerKey := strings.TrimSpace(string(config))
-		if pm, ok := supportedManagers[managerKey]; ok {
-			color.Yellow("Found '%s' config file, using %s.", uniConfigFile, pm.Name)
-			return pm, nil
+		if newest == "" || newerVersion(v, newest) {
+			newest = v
 		}
 	}
+	return newest
+}
 
-	for key, pm := range supportedManagers {
-		// Check for lock files first
-		for _, lockFile := range pm.LockFiles {
-			if _, err := os.Stat(lockFile); err == nil {
-				color.Yellow("Found '%s' lock file, using %s.", lockFile, pm.Name)
-				return pm, nil
-			}
-		}
-		if key == "pod" {
-			if _, err := os.Stat("Podfile"); err == nil {
-				return supportedManagers[key], nil
+// managerSearchResults is one manager's share of a `--pkg=all` search.
+type managerSearchResults struct {
+	Manager string          `json:"manager"`
+	Results []PackageResult `json:"results"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// handleSearchAll searches every manager with search support at once,
+// skipping CLI-based ones that aren't installed and managers that share
+// another's registry.
+func handleSearchAll(query string, opts searchOptions) error {
+	var managers []PackageManagerInfo
+	for _, key := range orderedManagerKeys() {
+		pm := supportedManagers[key]
+		switch {
+		case !pm.SearchAPISupport:
+		case slices.Contains([]string{"PNPM", "Yarn", "Bun", "Stack"}, pm.Name):
+		case offline && searchNeedsNetwork(pm):
+		case slices.Contains([]string{"Homebrew", "Snap", "Flatpak", "vcpkg", "Conan"}, pm.Name):
+			if _, err := lookPathCached(pm.Executable); err == nil {
+				managers = append(managers, pm)
 			}
+		default:
+			managers = append(managers, pm)
 		}
 	}
 
-	for _, pm := range supportedManagers {
-		// Check for metadata files like package.json, Podfile, etc.
-		for _, metaFile := range pm.MetadataFiles {
-			if _, err := os.Stat(metaFile); err == nil {
-				color.Yellow("Found '%s' metadata file, using %s.", metaFile, pm.Name)
-				return pm, nil
+	color.Cyan("🔍 Searching for '%s' using %d package managers...", query, len(managers))
+	// Deduplicating needs every result first, so it can't stream.
+	if opts.JSONLines && !opts.DedupeResults {
+		return streamSearchAll(managers, query, opts)
+	}
+	spin := startSpinner("Waiting for results...")
+	found := make([]managerSearchResults, len(managers))
+	var wg sync.WaitGroup
+	for i, pm := range managers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// fetchSearchResults takes the job slots; holding one here too
+			// would deadlock with --jobs=1.
+			results, _, err := fetchSearchResults(pm, query, opts)
+			found[i] = managerSearchResults{Manager: pm.Name, Results: results}
+			if err != nil {
+				found[i].Error = err.Error()
 			}
-		}
+			if found[i].Results == nil {
+				found[i].Results = []PackageResult{}
+			}
+		}()
 	}
+	wg.Wait()
+	spin.stop()
 
-	color.Yellow("No project file detected, falling back to system package manager.")
-	if _, err := exec.LookPath("brew"); err == nil {
-		return supportedManagers["brew"], nil
+	out, finish, err := searchOutput(opts.Output)
+	if err != nil {
+		return err
 	}
-	return supportedManagers["pkgx"], nil
-}
-
-func handleInit(managerKey string) {
-	pm, ok := supportedManagers[managerKey]
-	if !ok {
-		color.Red("Error: Package manager '%s' is not supported for init.", managerKey)
-		os.Exit(1)
+	if opts.DedupeResults {
+		return printDedupedResults(out, finish, found, query, opts)
 	}
-	color.Green("Initializing new %s project...", pm.Name)
-	err := os.WriteFile(uniConfigFile, []byte(managerKey), 0644)
-	if err != nil {
-		color.Red("Failed to write %s file: %v", uniConfigFile, err)
-		os.Exit(1)
+	if jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(found); err != nil {
+			return err
+		}
+		return finish()
 	}
-	color.Green("Created '%s' to use %s in this directory.", uniConfigFile, pm.Name)
-	if pm.InitArgs != nil {
+	for _, group := range found {
+		fmt.Fprintln(out, color.CyanString("== %s (%d) ==", group.Manager, len(group.Results)))
+		if group.Error != "" {
+			fmt.Fprintln(out, color.RedString("Search failed: %s", group.Error))
+		}
+		for i, result := range group.Results {
+			printPackageInfo(out, i+1, result, query, opts.MaxDesc)
+		}
+	}
+	return finish()
+}
+
+// normalizeResultName folds the spellings registries use for one package,
+// ignoring case and treating `_` and `.` like `-`, as PyPI does. Scoped npm
+// names stay distinct from unscoped ones.
+func normalizeResultName(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+}
+
+// dedupeResults collapses results with the same normalized name into the
+// first one found, in manager order, listing every manager that has it.
+func dedupeResults(found []managerSearchResults) []PackageResult {
+	var merged []PackageResult
+	index := map[string]int{}
+	for _, group := range found {
+		for _, result := range group.Results {
+			key := normalizeResultName(result.Name)
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				result.Providers = []string{group.Manager}
+				merged = append(merged, result)
+				continue
+			}
+			if !slices.Contains(merged[i].Providers, group.Manager) {
+				merged[i].Providers = append(merged[i].Providers, group.Manager)
+			}
+			if merged[i].Description == "" {
+				merged[i].Description = result.Description
+			}
+		}
+	}
+	return merged
+}
+
+// printDedupedResults writes a `--pkg=all --dedupe-results` search as one
+// combined list, reporting any manager whose search failed.
+func printDedupedResults(out io.Writer, finish func() error, found []managerSearchResults, query string, opts searchOptions) error {
+	for _, group := range found {
+		if group.Error != "" {
+			color.Red("%s search failed: %s", group.Manager, group.Error)
+		}
+	}
+	merged := dedupeResults(found)
+	switch {
+	case opts.JSONLines:
+		if err := writeJSONLines(out, merged); err != nil {
+			return err
+		}
+	case jsonOutput:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if merged == nil {
+			merged = []PackageResult{}
+		}
+		if err := encoder.Encode(merged); err != nil {
+			return err
+		}
+	default:
+		for i, result := range merged {
+			printPackageInfo(out, i+1, result, query, opts.MaxDesc)
+		}
+		if len(merged) > 0 {
+			fmt.Fprintln(out, color.YellowString("---"))
+			fmt.Fprintf(out, "%d distinct packages.\n", len(merged))
+		}
+	}
+	return finish()
+}
+
+// streamSearchAll is handleSearchAll for --json-lines: each manager's
+// results are written as soon as its search finishes, so lines arrive in
+// completion order rather than manager order. Failures go to stderr.
+func streamSearchAll(managers []PackageManagerInfo, query string, opts searchOptions) error {
+	out, finish, err := searchOutput(opts.Output)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var writeErr error
+	var wg sync.WaitGroup
+	for _, pm := range managers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, _, err := fetchSearchResults(pm, query, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				color.Red("%s search failed: %v", pm.Name, err)
+				return
+			}
+			if err := writeJSONLines(out, results); err != nil && writeErr == nil {
+				writeErr = err
+			}
+		}()
+	}
+	wg.Wait()
+	if writeErr != nil {
+		finish()
+		return writeErr
+	}
+	return finish()
+}
+
+// writeJSONLines writes results as newline-delimited JSON, one compact
+// object per line.
+func writeJSONLines(out io.Writer, results []PackageResult) error {
+	encoder := json.NewEncoder(out)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchOutput returns where search results go: stdout, or the --output
+// file, created with its parent directories and written without colors.
+// finish closes the file and reports its path.
+func searchOutput(path string) (out io.Writer, finish func() error, err error) {
+	if path == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	noColor := color.NoColor
+	color.NoColor = true
+	return file, func() error {
+		color.NoColor = noColor
+		if err := file.Close(); err != nil {
+			return err
+		}
+		color.Green("Wrote search results to %s.", path)
+		return nil
+	}, nil
+}
+
+// interactive reports whether uni may prompt: both ends of the session are a
+// terminal and neither --no-color nor --json asked for plain output.
+func interactive() bool {
+	if color.NoColor || jsonOutput {
+		return false
+	}
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no.
+func confirm(question string) bool {
+	fmt.Print(color.CyanString("%s [y/N]: ", question))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// promptInstall asks which search result to install and installs it with pm.
+func promptInstall(pm PackageManagerInfo, results []PackageResult) error {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print(color.CyanString("Install which? [1-%d/q]: ", len(results)))
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" || answer == "q" || err != nil {
+			return nil
+		}
+		n, convErr := strconv.Atoi(answer)
+		if convErr != nil || n < 1 || n > len(results) {
+			color.Yellow("Enter a number from 1 to %d, or q to quit.", len(results))
+			continue
+		}
+		return runCliCommand(pm, []string{"install", results[n-1].Name})
+	}
+}
+
+// parseSinceDuration parses a --since window. Besides Go durations such as
+// 36h it accepts days and weeks, e.g. 30d or 2w.
+func parseSinceDuration(value string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	} else if since, err := time.ParseDuration(value); err == nil && since > 0 {
+		return since, nil
+	}
+	return 0, fmt.Errorf("--since expects a positive duration such as 30d, 2w or 12h, got '%s'", value)
+}
+
+// searchOptions holds the flags accepted by `uni search`.
+type searchOptions struct {
+	Open              int           // 1-based index of the result to open in the browser, 0 for none
+	Since             time.Duration // Drop packages not published within this window, 0 for no limit
+	Tap               string        // Only keep Homebrew results from this tap, e.g. homebrew/core
+	Exact             bool          // Only keep the result named exactly like the query
+	Output            string        // File to write results to instead of stdout
+	BrewType          string        // Only keep Homebrew results of this type, "formula" or "cask"
+	Registry          string        // npm registry to search instead of the configured one
+	MinScore          float64       // Drop npm results whose score.final is below this, 0 for no limit
+	JSONLines         bool          // Write one JSON object per result instead of a JSON array
+	MaxDesc           int           // Characters of each description to print, 0 for all
+	IncludePrerelease bool          // Show pre-release versions instead of only stable ones
+	LatestOnly        bool          // Look up each result's latest version in its registry metadata
+	DedupeResults     bool          // Merge --pkg=all results that share a name across managers
+}
+
+// parseSearchArgs separates `uni search` flags from the query terms.
+func parseSearchArgs(args []string) (searchOptions, string, error) {
+	opts := searchOptions{MaxDesc: defaultMaxDesc}
+	var terms []string
+	for _, arg := range args {
+		switch {
+		case arg == "--open":
+			opts.Open = 1
+		case strings.HasPrefix(arg, "--open="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--open="))
+			if err != nil || n < 1 {
+				return opts, "", fmt.Errorf("--open expects a result number starting at 1, got '%s'", strings.TrimPrefix(arg, "--open="))
+			}
+			opts.Open = n
+		case strings.HasPrefix(arg, "--output="):
+			opts.Output = strings.TrimPrefix(arg, "--output=")
+		case arg == "--exact":
+			opts.Exact = true
+		case arg == "--json-lines":
+			opts.JSONLines = true
+		case arg == "--dedupe-results":
+			opts.DedupeResults = true
+		case strings.HasPrefix(arg, "--max-desc="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-desc="))
+			if err != nil || n < 0 {
+				return opts, "", fmt.Errorf("--max-desc expects a number of characters, or 0 for no limit, got '%s'", strings.TrimPrefix(arg, "--max-desc="))
+			}
+			opts.MaxDesc = n
+		case arg == "--latest-only":
+			opts.LatestOnly = true
+		case arg == "--include-prerelease":
+			opts.IncludePrerelease = true
+		case arg == "--cask" || arg == "--formula":
+			brewType := strings.TrimPrefix(arg, "--")
+			if opts.BrewType != "" && opts.BrewType != brewType {
+				return opts, "", errors.New("--cask and --formula can't be combined")
+			}
+			opts.BrewType = brewType
+		case strings.HasPrefix(arg, "--registry="):
+			registry := strings.TrimSuffix(strings.TrimPrefix(arg, "--registry="), "/")
+			if u, err := url.Parse(registry); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return opts, "", fmt.Errorf("--registry expects an http(s) URL such as https://registry.npmmirror.com, got '%s'", registry)
+			}
+			opts.Registry = registry
+		case strings.HasPrefix(arg, "--min-score="):
+			score, err := strconv.ParseFloat(strings.TrimPrefix(arg, "--min-score="), 64)
+			if err != nil || score < 0 || score > 1 {
+				return opts, "", fmt.Errorf("--min-score expects a number from 0 to 1, got '%s'", strings.TrimPrefix(arg, "--min-score="))
+			}
+			opts.MinScore = score
+		case strings.HasPrefix(arg, "--tap="):
+			opts.Tap = strings.TrimPrefix(arg, "--tap=")
+		case strings.HasPrefix(arg, "--since="):
+			since, err := parseSinceDuration(strings.TrimPrefix(arg, "--since="))
+			if err != nil {
+				return opts, "", err
+			}
+			opts.Since = since
+		case strings.HasPrefix(arg, "--"):
+			return opts, "", fmt.Errorf("unknown search flag '%s'", arg)
+		default:
+			terms = append(terms, arg)
+		}
+	}
+	return opts, strings.Join(terms, " "), nil
+}
+
+// openSearchResult opens the homepage of the nth result in the default
+// browser, falling back to its source URL.
+func openSearchResult(results []PackageResult, n int) {
+	if n > len(results) {
+		color.Yellow("Can't open result %d, the search returned %d.", n, len(results))
+		return
+	}
+	target := results[n-1].Homepage
+	if source := results[n-1].Source; target == "" && strings.HasPrefix(source, "http") {
+		target = strings.TrimSuffix(source, ".git")
+	}
+	if target == "" {
+		color.Yellow("%s has no homepage to open.", results[n-1].Name)
+		return
+	}
+	color.Cyan("🌐 Opening %s...", target)
+	if err := openBrowser(target); err != nil {
+		color.Red("Could not open a browser: %v", err)
+	}
+}
+
+func openBrowser(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		// start is a cmd builtin; the empty argument is the window title.
+		cmd = exec.Command("cmd", "/c", "start", "", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	return cmd.Start()
+}
+
+// searchHomebrewCliJson searches formulae and casks, or only one of them
+// when brewType is "formula" or "cask".
+func searchHomebrewCliJson(query, tap, brewType string) ([]PackageResult, int, error) {
+	searchArgs := []string{"search", query}
+	if brewType != "" {
+		searchArgs = []string{"search", "--" + brewType, query}
+	}
+	if _, err := lookPathCached("brew"); err != nil {
+		return nil, 0, &uniError{Category: ErrManagerNotInstalled, Err: errors.New("searching Homebrew needs brew, which is not installed or not in your PATH"), Hint: supportedManagers["brew"].InstallationHint}
+	}
+	searchCmd := exec.Command("brew", searchArgs...)
+	var searchOut bytes.Buffer
+	searchCmd.Stdout = &searchOut
+	acquireJob()
+	// brew exits non-zero when nothing matches, so a failure is handled like
+	// empty output.
+	searchCmd.Run()
+	releaseJob()
+
+	var names []string
+	scanner := bufio.NewScanner(&searchOut)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// `brew search` can have headers or empty lines, we ignore them.
+		if strings.HasPrefix(line, "==>") || line == "" {
+			continue
+		}
+		names = append(names, strings.Fields(line)[0]) // Get the first word of the line
+	}
+	if len(names) == 0 {
+		color.Yellow("No formulae or casks found for '%s'.", query)
+		return nil, 0, nil
+	}
+
+	// Failed lookups and unparsable JSON leave an empty response, which is
+	// skipped.
+	infos, _ := fetchDetails(names, func(name string) (BrewCliInfoResponse, error) {
+		var info BrewCliInfoResponse
+		infoCmd := exec.Command("brew", "info", "--json=v2", name)
+		var infoOut bytes.Buffer
+		infoCmd.Stdout = &infoOut
+		if err := infoCmd.Run(); err != nil {
+			return info, err
+		}
+		return info, json.Unmarshal(infoOut.Bytes(), &info)
+	})
+
+	// `brew search` lists aliases next to the formula they point at, so
+	// results are keyed by full_name and every other name becomes an alias.
+	var order []string
+	byName := map[string]*PackageResult{}
+	aliases := map[string][]string{}
+	formulae, casks := map[string]bool{}, map[string]bool{}
+	addResult := func(fullName, canonical, searched string, info PackageResult) {
+		if _, seen := byName[fullName]; !seen {
+			order = append(order, fullName)
+			byName[fullName] = &info
+		}
+		if searched != canonical && !slices.Contains(aliases[fullName], searched) {
+			aliases[fullName] = append(aliases[fullName], searched)
+		}
+	}
+	for i, info := range infos {
+		for _, item := range info.Formulae {
+			if brewType == "cask" || tap != "" && !strings.EqualFold(item.Tap, tap) {
+				continue
+			}
+			formulae[item.Name] = true
+			addResult("formula:"+item.FullName, item.Name, names[i], PackageResult{
+				Name:        item.Name,
+				Description: item.Desc,
+				License:     item.License,
+				Type:        "Formula",
+				Tap:         item.Tap,
+				Homepage:    item.Homepage,
+			})
+		}
+		for _, item := range info.Casks {
+			if brewType == "formula" || tap != "" && !strings.EqualFold(item.Tap, tap) {
+				continue
+			}
+			casks[item.Token] = true
+			addResult("cask:"+item.FullName, item.Token, names[i], PackageResult{
+				Name:        item.Token,
+				Description: item.Desc,
+				Type:        "Cask",
+				Tap:         item.Tap,
+				Homepage:    item.Homepage,
+			})
+		}
+	}
+
+	var results []PackageResult
+	for _, key := range order {
+		info := byName[key]
+		if len(aliases[key]) > 0 {
+			info.Aliases = strings.Join(aliases[key], ", ")
+		}
+		if formulae[info.Name] && casks[info.Name] {
+			info.Note = "Available as both a formula and a cask"
+		}
+		results = append(results, *info)
+	}
+
+	if len(order) == 0 {
+		// Every match was dropped by --tap, --cask or --formula.
+		color.Yellow("No formulae or casks found for '%s' with the given filters.", query)
+	}
+
+	return results, len(results), nil
+}
+
+// queryNPMSearch runs one search against the registry's /-/v1/search
+// endpoint.
+func queryNPMSearch(registry, token, text string, size int) (NPMRegistrySearchResult, error) {
+	req, err := http.NewRequest(http.MethodGet, registry+"/-/v1/search?text="+url.QueryEscape(text)+"&size="+strconv.Itoa(size), nil)
+	if err != nil {
+		return NPMRegistrySearchResult{}, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return NPMRegistrySearchResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return NPMRegistrySearchResult{}, newError(ErrNetwork, "registry %s rejected the request (%s); check UNI_NPM_TOKEN or the _authToken in .npmrc", registry, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return NPMRegistrySearchResult{}, err
+	}
+	response, err := decodeNPMSearch(body)
+	if err != nil {
+		return NPMRegistrySearchResult{}, fmt.Errorf("could not parse NPM response: %w", err)
+	}
+	return response, nil
+}
+
+// suggestNPMNames looks for likely spellings of a query that found nothing.
+// It searches again with a relaxed query, the words split apart or, for a
+// single word, just its first half, and keeps the names closest to the
+// original. It is best-effort: any failure just means no suggestions.
+func suggestNPMNames(registry, token, query string) []string {
+	relaxed := strings.Join(strings.FieldsFunc(query, func(r rune) bool { return strings.ContainsRune(" -_./@", r) }), " ")
+	if relaxed == query || relaxed == "" {
+		if len(query) < 4 {
+			return nil
+		}
+		relaxed = query[:max(3, len(query)/2)]
+	}
+	response, err := queryNPMSearch(registry, token, relaxed, 20)
+	if err != nil {
+		logVerbose("suggestions for %q: %v", query, err)
+		return nil
+	}
+	limit := max(1, len(query)/3)
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for _, item := range response.Objects {
+		if d := editDistance(strings.ToLower(query), strings.ToLower(item.Package.Name)); d <= limit {
+			candidates = append(candidates, candidate{item.Package.Name, d})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.distance - b.distance })
+	var names []string
+	for _, c := range candidates[:min(3, len(candidates))] {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur := make([]int, len(y)+1)
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(y)]
+}
+
+func searchNPM(query string, opts searchOptions) ([]PackageResult, int, error) {
+	since := opts.Since
+	registry, token := searchRegistry(opts)
+	if opts.Exact {
+		return fetchNPMPackage(registry, token, query)
+	}
+	response, err := queryNPMSearch(registry, token, query, 10)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(response.Objects) == 0 {
+		color.Yellow("No packages found.")
+		if !jsonOutput {
+			if names := suggestNPMNames(registry, token, query); len(names) > 0 {
+				color.Yellow("Did you mean %s?", strings.Join(names, ", "))
+			}
+		}
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	skipped, lowScore := 0, 0
+	for _, item := range response.Objects {
+		if item.Score.Final < opts.MinScore {
+			lowScore++
+			continue
+		}
+		pkg := item.Package
+		published := pkg.Date
+		if since > 0 {
+			if published == "" {
+				published = fetchNPMModified(registry, token, pkg.Name)
+			}
+			// Packages without a usable date are kept rather than guessed at.
+			if t, err := time.Parse(time.RFC3339, published); err == nil && time.Since(t) > since {
+				skipped++
+				continue
+			}
+		}
+		results = append(results, PackageResult{
+			Name:        pkg.Name,
+			Scope:       packageScope(pkg.Name, "@"),
+			Description: pkg.Description,
+			Version:     pkg.Version,
+			Homepage:    pkg.Links.Homepage,
+			Author:      pkg.Author.Name,
+			Published:   published,
+		})
+	}
+	if skipped > 0 {
+		color.Yellow("Hid %d package(s) not published in the last %s.", skipped, since)
+	}
+	if lowScore > 0 {
+		color.Yellow("Hid %d package(s) scoring below %g.", lowScore, opts.MinScore)
+	}
+	return results, response.Total - skipped - lowScore, nil
+}
+
+// searchRegistry returns the npm registry a search uses, --registry or the
+// configured one, and its auth token.
+func searchRegistry(opts searchOptions) (registry, token string) {
+	registry, token = npmRegistryConfig()
+	if opts.Registry != "" && opts.Registry != registry {
+		// The configured token belongs to the configured registry only.
+		registry, token = opts.Registry, ""
+		logVerbose("searching %s instead of the configured registry", registry)
+	}
+	return registry, token
+}
+
+// newNPMDocumentRequest builds a request for a package's registry document.
+func newNPMDocumentRequest(registry, token, name string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, registry+"/"+strings.Replace(url.PathEscape(name), "%40", "@", 1), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// decodeNPMSearch parses an npm search response. When strict decoding fails,
+// e.g. on trailing data or one object of an unexpected shape, it falls back
+// to decoding the objects one by one and keeps those that parse.
+func decodeNPMSearch(body []byte) (NPMRegistrySearchResult, error) {
+	var response NPMRegistrySearchResult
+	strictErr := json.Unmarshal(body, &response)
+	if strictErr == nil {
+		return response, nil
+	}
+
+	var lenient struct {
+		Objects []json.RawMessage `json:"objects"`
+		Total   json.RawMessage   `json:"total"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&lenient); err != nil {
+		return NPMRegistrySearchResult{}, strictErr
+	}
+	response = NPMRegistrySearchResult{}
+	json.Unmarshal(lenient.Total, &response.Total)
+	skipped := 0
+	for _, raw := range lenient.Objects {
+		var object NPMSearchObject
+		if err := json.Unmarshal(raw, &object); err != nil {
+			skipped++
+			continue
+		}
+		response.Objects = append(response.Objects, object)
+	}
+	logVerbose("npm search response didn't decode cleanly (%v); kept %d objects, skipped %d.", strictErr, len(response.Objects), skipped)
+	return response, nil
+}
+
+// fetchNPMPackage looks up a package by its exact name, for search --exact.
+func fetchNPMPackage(registry, token, name string) ([]PackageResult, int, error) {
+	req, err := newNPMDocumentRequest(registry, token, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, 0, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, 0, newError(ErrNetwork, "registry %s rejected the request (%s); check UNI_NPM_TOKEN or the _authToken in .npmrc", registry, resp.Status)
+	default:
+		return nil, 0, newError(ErrNetwork, "registry %s returned %s", registry, resp.Status)
+	}
+	var doc struct {
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		DistTags    map[string]string `json:"dist-tags"`
+		Homepage    string            `json:"homepage"`
+		Author      struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Time map[string]string `json:"time"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, 0, fmt.Errorf("could not parse NPM response: %w", err)
+	}
+	latest := doc.DistTags["latest"]
+	return []PackageResult{{
+		Name:        doc.Name,
+		Scope:       packageScope(doc.Name, "@"),
+		Description: doc.Description,
+		Version:     latest,
+		Homepage:    doc.Homepage,
+		Author:      doc.Author.Name,
+		Published:   doc.Time[latest],
+	}}, 1, nil
+}
+
+// fetchNPMModified returns the `time.modified` timestamp from a package's
+// registry document, or "" when it can't be fetched.
+func fetchNPMModified(registry, token, name string) string {
+	req, err := newNPMDocumentRequest(registry, token, name)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Accept", "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	var doc struct {
+		Modified string `json:"modified"`
+		Time     struct {
+			Modified string `json:"modified"`
+		} `json:"time"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return ""
+	}
+	if doc.Time.Modified != "" {
+		return doc.Time.Modified
+	}
+	return doc.Modified
+}
+
+// npmRegistryConfig returns the registry used for npm search and, for
+// private registries, the auth token to send with requests. The registry
+// comes from UNI_NPM_REGISTRY or the `registry` key of .npmrc; the token
+// from UNI_NPM_TOKEN or the registry's `_authToken` entry in .npmrc.
+func npmRegistryConfig() (registry, token string) {
+	npmrc := readNpmrc()
+	registry = os.Getenv("UNI_NPM_REGISTRY")
+	if registry == "" {
+		registry = npmrc["registry"]
+	}
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" || registry == defaultNPMRegistry {
+		// Never hand a private token to the public registry.
+		return defaultNPMRegistry, ""
+	}
+
+	if token = os.Getenv("UNI_NPM_TOKEN"); token != "" {
+		return registry, token
+	}
+	// .npmrc scopes tokens by registry URL without its scheme, e.g.
+	// //npm.example.com/api/:_authToken=...; the longest matching prefix wins.
+	hostPath := registry
+	if _, rest, ok := strings.Cut(registry, "://"); ok {
+		hostPath = rest
+	}
+	scoped := "//" + hostPath + "/"
+	var bestMatch string
+	for key, value := range npmrc {
+		prefix, ok := strings.CutSuffix(key, ":_authToken")
+		if !ok || !strings.HasPrefix(scoped, prefix) || len(prefix) <= len(bestMatch) {
+			continue
+		}
+		bestMatch = prefix
+		token = os.ExpandEnv(value)
+	}
+	return registry, token
+}
+
+// readNpmrc merges the user's ~/.npmrc with the project's .npmrc, the
+// project file taking precedence.
+func readNpmrc() map[string]string {
+	config := map[string]string{}
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".npmrc"))
+	}
+	paths = append(paths, projectPath(".npmrc"))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+				continue
+			}
+			if key, value, ok := strings.Cut(line, "="); ok {
+				config[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+	}
+	return config
+}
+
+// cocoaPodsSearchInterval is the least time left between CocoaPods searches,
+// even across separate runs, so scripted searches don't get rate limited.
+const cocoaPodsSearchInterval = time.Second
+
+// throttleCocoaPods waits until cocoaPodsSearchInterval has passed since the
+// last search, tracked by the mtime of a file in uniCacheDir.
+func throttleCocoaPods() {
+	cacheDir, err := uniCacheDir()
+	if err != nil {
+		return
+	}
+	stamp := filepath.Join(cacheDir, "cocoapods-last-search")
+	if info, err := os.Stat(stamp); err == nil {
+		if wait := cocoaPodsSearchInterval - time.Since(info.ModTime()); wait > 0 {
+			logVerbose("search.cocoapods.org: throttling for %s", wait.Round(time.Millisecond))
+			time.Sleep(wait)
+		}
+	}
+	if os.MkdirAll(filepath.Dir(stamp), 0755) == nil && os.WriteFile(stamp, nil, 0644) == nil {
+		now := time.Now()
+		os.Chtimes(stamp, now, now)
+	}
+}
+
+func searchCocoaPods(query string) ([]PackageResult, int, error) {
+	throttleCocoaPods()
+	resp, err := getWithRetry("https://search.cocoapods.org/api/v1/pods.flat.hash.json?query=" + url.QueryEscape(query) + "&amount=10")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, 0, &uniError{Category: ErrNetwork, Err: errors.New("search.cocoapods.org is rate limiting searches"), Hint: "Wait a minute and search again."}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newError(ErrNetwork, "search.cocoapods.org returned %s", resp.Status)
+	}
+	var response CocoaPodsAPISearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("could not parse CocoaPods response: %w", err)
+	}
+	if response.Total == 0 {
+		color.Yellow("No pods found.")
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	for _, item := range response.Results {
+		results = append(results, PackageResult{
+			Name:        item.ID,
+			Scope:       packageScope(item.ID, ""),
+			Description: item.Summary,
+			Version:     item.Version,
+			Source:      item.Source.Git,
+		})
+	}
+	return results, response.Total, nil
+}
+
+var snapFindRow = regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s*(.*)$`)
+
+// searchSnap parses the table printed by `snap find`. Name, version,
+// publisher and notes never contain spaces, so the summary is whatever
+// follows the fourth column.
+func searchSnap(query string) ([]PackageResult, int, error) {
+	findCmd := exec.Command("snap", "find", query)
+	var findOut bytes.Buffer
+	findCmd.Stdout = &findOut
+	if err := findCmd.Run(); err != nil {
+		// snap exits non-zero when nothing matches.
+		color.Yellow("No snaps found.")
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	scanner := bufio.NewScanner(&findOut)
+	for scanner.Scan() {
+		fields := snapFindRow.FindStringSubmatch(scanner.Text())
+		if fields == nil || fields[1] == "Name" {
+			continue
+		}
+		results = append(results, PackageResult{
+			Name:        fields[1],
+			Version:     fields[2],
+			Author:      strings.TrimSuffix(fields[3], "✓"),
+			Description: strings.TrimSpace(fields[5]),
+			Homepage:    "https://snapcraft.io/" + fields[1],
+		})
+	}
+	if len(results) == 0 {
+		color.Yellow("No snaps found.")
+	}
+	return results, len(results), nil
+}
+
+// searchFlatpak runs `flatpak search`, which prints tab-separated columns
+// when its output isn't a terminal.
+func searchFlatpak(query string) ([]PackageResult, int, error) {
+	searchCmd := exec.Command("flatpak", "search", "--columns=application,version,remotes,name,description", query)
+	var searchOut bytes.Buffer
+	searchCmd.Stdout = &searchOut
+	if err := searchCmd.Run(); err != nil {
+		return nil, 0, fmt.Errorf("flatpak search failed: %w", err)
+	}
+	var results []PackageResult
+	scanner := bufio.NewScanner(&searchOut)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 || fields[0] == "Application ID" {
+			continue
+		}
+		results = append(results, PackageResult{
+			Name:        fields[0],
+			Version:     fields[1],
+			Source:      fields[2],
+			Description: fields[3] + " - " + fields[4],
+		})
+	}
+	if len(results) == 0 {
+		color.Yellow("No apps found.")
+	}
+	return results, len(results), nil
+}
+
+// vcpkgSearchRow matches a port in `vcpkg search` output: a lowercase name,
+// its version and a description. Feature rows such as `curl[http2]` have no
+// version column and are skipped, as are the notes vcpkg prints after the
+// table, which start with a capital letter.
+var vcpkgSearchRow = regexp.MustCompile(`^([a-z0-9][a-z0-9-]*)\s+(\S+)\s+(.*)$`)
+
+// searchVcpkg parses the table printed by `vcpkg search`.
+func searchVcpkg(query string) ([]PackageResult, int, error) {
+	searchCmd := exec.Command("vcpkg", "search", query)
+	var searchOut bytes.Buffer
+	searchCmd.Stdout = &searchOut
+	if err := searchCmd.Run(); err != nil {
+		return nil, 0, fmt.Errorf("vcpkg search failed: %w", err)
+	}
+	var results []PackageResult
+	scanner := bufio.NewScanner(&searchOut)
+	for scanner.Scan() {
+		fields := vcpkgSearchRow.FindStringSubmatch(scanner.Text())
+		if fields == nil {
+			continue
+		}
+		results = append(results, PackageResult{
+			Name:        fields[1],
+			Version:     fields[2],
+			Description: strings.TrimSpace(fields[3]),
+			Homepage:    "https://vcpkg.io/en/package/" + fields[1],
+		})
+	}
+	if len(results) == 0 {
+		color.Yellow("No ports found.")
+	}
+	return results, len(results), nil
+}
+
+// searchConan runs `conan search` against the configured remotes. Conan 2
+// only matches whole names, so the query is wrapped in wildcards. Output
+// lists each remote unindented, then `name/version[@user/channel]`
+// references, oldest first; each package keeps its newest version.
+func searchConan(query string) ([]PackageResult, int, error) {
+	searchCmd := exec.Command("conan", "search", "*"+query+"*")
+	var searchOut bytes.Buffer
+	searchCmd.Stdout = &searchOut
+	if err := searchCmd.Run(); err != nil {
+		return nil, 0, fmt.Errorf("conan search failed: %w", err)
+	}
+	var results []PackageResult
+	seen := map[string]int{}
+	remote := ""
+	scanner := bufio.NewScanner(&searchOut)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		name, version, ok := strings.Cut(trimmed, "/")
+		if !ok {
+			// Remote headings are the only unindented lines without a slash.
+			if line == trimmed && !strings.HasSuffix(trimmed, ":") {
+				remote = trimmed
+			}
+			continue
+		}
+		version, _, _ = strings.Cut(version, "@")
+		key := remote + "|" + name
+		if i, ok := seen[key]; ok {
+			results[i].Version = version
+			continue
+		}
+		seen[key] = len(results)
+		results = append(results, PackageResult{Name: name, Version: version, Source: remote})
+	}
+	if len(results) == 0 {
+		color.Yellow("No Conan recipes found.")
+	}
+	return results, len(results), nil
+}
+
+func searchJSR(query string) ([]PackageResult, int, error) {
+	resp, err := httpClient.Get("https://jsr.io/api/packages?limit=10&query=" + url.QueryEscape(query))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newError(ErrNetwork, "jsr.io returned %s", resp.Status)
+	}
+	var response JSRSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("could not parse JSR response: %w", err)
+	}
+	if len(response.Items) == 0 {
+		color.Yellow("No packages found.")
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	for _, pkg := range response.Items {
+		name := "@" + pkg.Scope + "/" + pkg.Name
+		results = append(results, PackageResult{
+			Name:        name,
+			Scope:       "@" + pkg.Scope,
+			Description: pkg.Description,
+			Version:     pkg.LatestVersion,
+			Homepage:    "https://jsr.io/" + name,
+		})
+	}
+	return results, response.Total, nil
+}
+
+// searchSwiftPackageIndex queries the Swift Package Index. The API expects a
+// token, read from UNI_SPI_TOKEN, from https://swiftpackageindex.com.
+func searchSwiftPackageIndex(query string) ([]PackageResult, int, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://swiftpackageindex.com/api/search?query="+url.QueryEscape(query), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if token := os.Getenv("UNI_SPI_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, 0, &uniError{
+			Category: ErrNetwork,
+			Err:      fmt.Errorf("Swift Package Index rejected the request (%s)", resp.Status),
+			Hint:     "Set UNI_SPI_TOKEN to an API token from https://swiftpackageindex.com.",
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newError(ErrNetwork, "Swift Package Index returned %s", resp.Status)
+	}
+	var response SwiftPackageIndexSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("could not parse Swift Package Index response: %w", err)
+	}
+	var results []PackageResult
+	for _, item := range response.Results {
+		// Results also include matching authors and keywords; only packages
+		// can be installed.
+		pkg := item.Package
+		if pkg == nil {
+			continue
+		}
+		results = append(results, PackageResult{
+			Name:        pkg.RepositoryOwner + "/" + pkg.RepositoryName,
+			Scope:       pkg.RepositoryOwner,
+			Description: pkg.Summary,
+			Homepage:    "https://swiftpackageindex.com" + pkg.PackageURL,
+		})
+		if len(results) == 10 {
+			break
+		}
+	}
+	if len(results) == 0 {
+		color.Yellow("No packages found.")
+	}
+	return results, len(results), nil
+}
+
+// searchHex lists Hex packages with their latest stable version, or their
+// latest version of any kind with includePrerelease.
+func searchHex(query string, includePrerelease bool) ([]PackageResult, int, error) {
+	resp, err := httpClient.Get("https://hex.pm/api/packages?search=" + url.QueryEscape(query) + "&sort=downloads")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newError(ErrNetwork, "hex.pm returned %s", resp.Status)
+	}
+	var response []HexPackage
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("could not parse Hex response: %w", err)
+	}
+	if len(response) == 0 {
+		color.Yellow("No packages found.")
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	for _, pkg := range response[:min(len(response), 10)] {
+		version := pkg.LatestStableVersion
+		if version == "" || includePrerelease {
+			version = pkg.LatestVersion
+		}
+		results = append(results, PackageResult{
+			Name:        pkg.Name,
+			Description: pkg.Meta.Description,
+			Version:     version,
+			License:     strings.Join(pkg.Meta.Licenses, ", "),
+			Homepage:    pkg.HTMLURL,
+		})
+	}
+	return results, len(response), nil
+}
+
+// searchHackage uses the JSON endpoint behind Hackage's package browser,
+// which takes the query as a POSTed JSON document.
+func searchHackage(query string) ([]PackageResult, int, error) {
+	body, err := json.Marshal(map[string]any{
+		"page":          0,
+		"sortColumn":    "default",
+		"sortDirection": "ascending",
+		"searchQuery":   query,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := httpClient.Post("https://hackage.haskell.org/packages/search", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newError(ErrNetwork, "Hackage returned %s", resp.Status)
+	}
+	var response HackageSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("could not parse Hackage response: %w", err)
+	}
+	if len(response.PageContents) == 0 {
+		color.Yellow("No packages found.")
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	for _, pkg := range response.PageContents[:min(len(response.PageContents), 10)] {
+		results = append(results, PackageResult{
+			Name:        pkg.Name.Display,
+			Description: pkg.Description,
+			Homepage:    "https://hackage.haskell.org" + pkg.Name.URI,
+		})
+	}
+	return results, response.NumberOfResults, nil
+}
+
+// searchPkgx filters the pantry index published on pkgx.dev, since pkgx
+// itself has no search endpoint. Name matches are listed before matches
+// found only in the description.
+func searchPkgx(query string) ([]PackageResult, int, error) {
+	resp, err := httpClient.Get("https://pkgx.dev/pkgs/index.json")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, newError(ErrNetwork, "pkgx.dev returned %s", resp.Status)
+	}
+	var pantry []PkgxPantryEntry
+	if err := json.NewDecoder(resp.Body).Decode(&pantry); err != nil {
+		return nil, 0, fmt.Errorf("could not parse pkgx pantry index: %w", err)
+	}
+
+	needle := strings.ToLower(query)
+	var nameMatches, descMatches []PkgxPantryEntry
+	for _, entry := range pantry {
+		switch {
+		case strings.Contains(strings.ToLower(entry.Project), needle), strings.Contains(strings.ToLower(entry.Name), needle):
+			nameMatches = append(nameMatches, entry)
+		case strings.Contains(strings.ToLower(entry.Description), needle):
+			descMatches = append(descMatches, entry)
+		}
+	}
+	matches := append(nameMatches, descMatches...)
+	if len(matches) == 0 {
+		color.Yellow("No packages found.")
+		return nil, 0, nil
+	}
+	var results []PackageResult
+	for _, entry := range matches[:min(len(matches), 10)] {
+		results = append(results, PackageResult{
+			Name:        entry.Project,
+			Description: entry.Description,
+			Homepage:    "https://pkgx.dev/pkgs/" + entry.Project + "/",
+		})
+	}
+	return results, len(matches), nil
+}
+
+// pkg.go.dev has no public search API, so results are scraped from the
+// search page's HTML snippets.
+var (
+	goSnippetPathRe     = regexp.MustCompile(`<a href="/([^"?#]+)"[^>]*data-test-id="snippet-title"`)
+	goSnippetSynopsisRe = regexp.MustCompile(`(?s)data-test-id="snippet-synopsis"[^>]*>(.*?)</p>`)
+	goSnippetVersionRe  = regexp.MustCompile(`<strong>(v[0-9][^<]*)</strong>`)
+)
+
+func searchGoPackages(query string) ([]PackageResult, int, error) {
+	acquireJob()
+	body, err := fetchGoSearchPage(query)
+	releaseJob()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var results []PackageResult
+	// The first chunk is the page header, every following one is a result.
+	for _, snippet := range strings.Split(body, `class="SearchSnippet"`)[1:] {
+		match := goSnippetPathRe.FindStringSubmatch(snippet)
+		if match == nil {
+			continue
+		}
+		modulePath := html.UnescapeString(match[1])
+		var synopsis, version string
+		if m := goSnippetSynopsisRe.FindStringSubmatch(snippet); m != nil {
+			synopsis = strings.TrimSpace(html.UnescapeString(m[1]))
+		}
+		if m := goSnippetVersionRe.FindStringSubmatch(snippet); m != nil {
+			version = m[1]
+		}
+		results = append(results, PackageResult{
+			Name:        modulePath,
+			Description: synopsis,
+			Version:     version,
+			Homepage:    "https://pkg.go.dev/" + modulePath,
+		})
+	}
+	if len(results) == 0 {
+		color.Yellow("No modules found.")
+	}
+	// The module proxy is authoritative for the latest version; the scraped
+	// one is kept for package paths that aren't module roots.
+	latest, errs := fetchDetails(results, func(result PackageResult) (string, error) {
+		return fetchGoProxyLatest(result.Name)
+	})
+	for i := range results {
+		if errs[i] == nil && latest[i] != "" {
+			results[i].Version = latest[i]
+		}
+	}
+	return results, len(results), nil
+}
+
+// fetchGoSearchPage returns the HTML of pkg.go.dev's results for query.
+func fetchGoSearchPage(query string) (string, error) {
+	resp, err := httpClient.Get("https://pkg.go.dev/search?q=" + url.QueryEscape(query) + "&limit=10")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", newError(ErrNetwork, "pkg.go.dev returned %s", resp.Status)
+	}
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(resp.Body); err != nil {
+		return "", fmt.Errorf("could not read pkg.go.dev response: %w", err)
+	}
+	return body.String(), nil
+}
+
+func fetchGoProxyLatest(modulePath string) (string, error) {
+	resp, err := httpClient.Get("https://proxy.golang.org/" + escapeGoModulePath(modulePath) + "/@latest")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("module proxy returned %s", resp.Status)
+	}
+	var latest GoProxyLatestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil {
+		return "", fmt.Errorf("could not parse module proxy response: %w", err)
+	}
+	return latest.Version, nil
+}
+
+// escapeGoModulePath applies the module proxy's case encoding, where each
+// upper-case letter is replaced by '!' followed by its lower-case form.
+func escapeGoModulePath(modulePath string) string {
+	var b strings.Builder
+	for _, r := range modulePath {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// packageScope returns the namespace of a `scope/name` package, such as
+// @angular for @angular/core or Firebase for the Firebase/Auth subspec. A
+// non-empty marker requires the name to start with it, as npm scopes do.
+func packageScope(name, marker string) string {
+	if !strings.HasPrefix(name, marker) {
+		return ""
+	}
+	scope, _, ok := strings.Cut(name, "/")
+	if !ok {
+		return ""
+	}
+	return scope
+}
+
+func printPackageInfo(w io.Writer, n int, info PackageResult, query string, maxDesc int) {
+	info.Description = truncateDescription(info.Description, maxDesc)
+	fmt.Fprintln(w, color.YellowString("--- [%d]", n))
+	keyColor := color.New(color.FgGreen)
+	terms := queryTermsPattern(query)
+	for _, field := range info.fields() {
+		key, val := field[0], field[1]
+		if terms != nil && (key == "Name" || key == "Description") {
+			val = terms.ReplaceAllStringFunc(val, func(term string) string { return highlight.Sprint(term) })
+		}
+		keyColor.Fprintf(w, "%-14s", key+":")
+		fmt.Fprintf(w, "%s\n", val)
+	}
+}
+
+// defaultMaxDesc is how many characters of a description search prints
+// unless --max-desc says otherwise.
+const defaultMaxDesc = 120
+
+// truncateDescription shortens desc to at most max characters, cutting at
+// the last word boundary and adding an ellipsis. A max of 0 keeps it whole.
+func truncateDescription(desc string, max int) string {
+	runes := []rune(desc)
+	if max <= 0 || len(runes) <= max {
+		return desc
+	}
+	cut := string(runes[:max-1])
+	if i := strings.LastIndexAny(cut, " \t\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \t\n.,;:") + "…"
+}
+
+// highlight marks query terms in search results; it prints plain text when
+// colors are off.
+var highlight = color.New(color.Bold, color.FgHiYellow)
+
+// queryTermsPattern matches any word of query, ignoring case, or is nil for
+// an empty query.
+func queryTermsPattern(query string) *regexp.Regexp {
+	var quoted []string
+	for _, term := range strings.Fields(query) {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+func executeCliCommand(pm PackageManagerInfo, args []string) {
+	if err := runCliCommand(pm, args); err != nil {
+		exitWithError(err)
+	}
+}
+
+// runCliCommand translates args for pm and runs the manager.
+func runCliCommand(pm PackageManagerInfo, args []string) error {
+	pm, env, err := applyVenv(pm, args)
+	if err != nil {
+		return err
+	}
+	if _, err := lookPathCached(pm.Executable); err != nil && !miseProvides(pm.Executable) && !installManager(pm) {
+		return &uniError{
+			Category: ErrManagerNotInstalled,
+			Err:      fmt.Errorf("%s (%s) is not installed or not in your PATH", pm.Name, pm.Executable),
+			Hint:     pm.InstallationHint,
+		}
+	}
+	pm = resolveVariant(pm)
+	var preview, summary bool
+	var stdout io.Writer = os.Stdout
+	var snapshotFile string
+	if len(args) > 0 {
+		switch args[0] {
+		case "install", "i", "add":
+			var retryFailed bool
+			if args, retryFailed = takeBoolFlag(args, "--retry-failed"); retryFailed {
+				i := slices.Index(args, "-")
+				if i < 1 {
+					err := newError(ErrUsage, "--retry-failed only applies to batch installs")
+					err.Hint = "Pass '-' to read the packages from stdin, or list them in a file for 'uni bundle'."
+					return err
+				}
+				pkgs, err := readPackageList(os.Stdin, "stdin")
+				if err != nil {
+					return &uniError{Category: ErrUsage, Err: err}
+				}
+				entries := make([]bundleEntry, len(pkgs))
+				for j, pkg := range pkgs {
+					entries[j] = bundleEntry{Manager: pm, Package: pkg}
+				}
+				return installBatch(entries, slices.Delete(slices.Clone(args), i, i+1)[1:], true, "stdin")
+			}
+			args, preview = takeBoolFlag(args, "--preview")
+			args, summary = takeBoolFlag(args, "--summary")
+			if i := slices.Index(args, "-"); i > 0 {
+				pkgs, err := readPackageList(os.Stdin, "stdin")
+				if err != nil {
+					return &uniError{Category: ErrUsage, Err: err}
+				}
+				args = slices.Concat(args[:i], pkgs, args[i+1:])
+			}
+			if pm.Name != "Homebrew" && (slices.Contains(args, "--cask") || slices.Contains(args, "--formula")) {
+				return newError(ErrUsage, "--cask and --formula only apply to Homebrew")
+			}
+			if slices.Contains(args, "--cask") && slices.Contains(args, "--formula") {
+				return newError(ErrUsage, "--cask and --formula can't be combined")
+			}
+			if checkEngines && slices.Contains([]string{"NPM", "PNPM", "Yarn"}, pm.Name) {
+				checkNodeEngines()
+			}
+			var frozen, manifestOnly bool
+			args, frozen = takeBoolFlag(args, "--frozen", "--immutable")
+			args, manifestOnly = takeBoolFlag(args, "--manifest-only")
+			var ignoreScripts, preferOffline bool
+			args, ignoreScripts = takeBoolFlag(args, "--ignore-scripts")
+			args, preferOffline = takeBoolFlag(args, "--prefer-offline")
+			var noLockfile, prerelease, exact bool
+			args, noLockfile = takeBoolFlag(args, "--no-lockfile")
+			args, prerelease = takeBoolFlag(args, "--include-prerelease")
+			args, exact = takeBoolFlag(args, "--exact", "-E")
+			if frozen && manifestOnly {
+				return newError(ErrUsage, "--frozen and --manifest-only can't be combined")
+			}
+			if noLockfile && (frozen || manifestOnly) {
+				return newError(ErrUsage, "--no-lockfile can't be combined with --frozen or --manifest-only, which need the lockfile")
+			}
+			if exact && (frozen || len(args) == 1) {
+				return newError(ErrUsage, "--exact pins the packages being added and needs package names")
+			}
+			if manifestOnly && pm.ManifestOnlyFlag == "" {
+				return newError(ErrManagerUnsupported, "%s can't update the manifest without installing", pm.Name)
+			}
+			if frozen {
+				if len(args) > 1 {
+					return newError(ErrUsage, "--frozen installs exactly what the lockfile lists and does not accept package names")
+				}
+				if pm.FrozenCmd == "" {
+					return newError(ErrManagerUnsupported, "%s does not support frozen installs", pm.Name)
+				}
+				args = strings.Fields(pm.FrozenCmd)
+			} else if len(args) == 1 && pm.InstallCmdWithoutArgs != "" {
+				args = strings.Fields(pm.InstallCmdWithoutArgs)
+				if pm.Name == "Pip" {
+					if manifestFile != "" {
+						args = []string{"install", "-r", manifestFile}
+					} else if _, err := os.Stat(projectPath("requirements.txt")); err != nil {
+						err := newError(ErrUsage, "no requirements.txt found")
+						err.Hint = "Pass the packages to install, e.g. 'uni install requests'."
+						return err
+					}
+				}
+			} else if pm.InstallCmd == "" {
+				err := newError(ErrManagerUnsupported, "%s does not have a standard install command", pm.Name)
+				err.Hint = pm.ManualInstallHint
+				return err
+			} else {
+				args = installSpecArgs(pm, args[1:])
+			}
+			var manifestEnv []string
+			args, manifestEnv = applyManifest(pm, args)
+			env = append(env, manifestEnv...)
+			if manifestOnly {
+				args = append(args, pm.ManifestOnlyFlag)
+			}
+			if ignoreScripts {
+				if pm.IgnoreScriptsFlag == "" {
+					color.Yellow("Warning: %s can't skip install scripts; --ignore-scripts has no effect.", pm.Name)
+				} else {
+					args = append(args, pm.IgnoreScriptsFlag)
+				}
+			}
+			if preferOffline {
+				if pm.PreferOfflineFlag == "" {
+					color.Yellow("Warning: %s has no offline-first mode; --prefer-offline has no effect.", pm.Name)
+				} else {
+					args = append(args, pm.PreferOfflineFlag)
+				}
+			}
+			if prerelease {
+				switch {
+				case slices.Contains([]string{"NPM", "PNPM", "Yarn", "Bun"}, pm.Name):
+					args = nodePrereleaseArgs(args)
+				case pm.PrereleaseFlag != "":
+					args = append(args, pm.PrereleaseFlag)
+				default:
+					color.Yellow("Warning: %s has no pre-release option; --include-prerelease has no effect.", pm.Name)
+				}
+			}
+			if noLockfile {
+				if pm.NoLockfileFlag == "" {
+					color.Yellow("Warning: %s has no option to skip writing a lockfile; --no-lockfile has no effect.", pm.Name)
+				} else {
+					args = append(args, pm.NoLockfileFlag)
+				}
+			}
+			if exact {
+				if pm.ExactFlag == "" {
+					color.Yellow("Warning: %s has no option to save exact versions; --exact has no effect.", pm.Name)
+				} else {
+					args = append(args, pm.ExactFlag)
+				}
+			}
+		case "uninstall", "remove", "rm", "un":
+			if pm.UninstallCmd == "" {
+				return newError(ErrManagerUnsupported, "%s does not have a standard uninstall command", pm.Name)
+			}
+			var packagesFrom string
+			if args, packagesFrom = takeValueFlag(args, "--packages-from"); packagesFrom != "" {
+				var yes bool
+				args, yes = takeBoolFlag(args, "--yes")
+				file, err := os.Open(packagesFrom)
+				if err != nil {
+					return &uniError{Category: ErrUsage, Err: err}
+				}
+				pkgs, err := readPackageList(file, packagesFrom)
+				file.Close()
+				if err != nil {
+					return &uniError{Category: ErrUsage, Err: err}
+				}
+				if !yes && !dryRun {
+					if !isatty.IsTerminal(os.Stdin.Fd()) {
+						return newError(ErrUsage, "pass --yes to remove the %d packages in %s without confirming", len(pkgs), packagesFrom)
+					}
+					if !confirm(fmt.Sprintf("Remove %d packages (%s) with %s?", len(pkgs), strings.Join(pkgs, ", "), pm.Name)) {
+						color.Yellow("Nothing removed.")
+						return nil
+					}
+				}
+				args = append(args, pkgs...)
+			}
+			args[0] = pm.UninstallCmd
+		case "dedupe", "ddp":
+			if pm.DedupeCmd == "" {
+				return newError(ErrManagerUnsupported, "%s does not support deduplicating dependencies", pm.Name)
+			}
+			args = append(strings.Fields(pm.DedupeCmd), args[1:]...)
+		case "prune":
+			if pm.PruneCmd == "" {
+				return newError(ErrManagerUnsupported, "%s does not support pruning extraneous packages", pm.Name)
+			}
+			args = append(strings.Fields(pm.PruneCmd), args[1:]...)
+		case "freeze", "export":
+			if pm.FreezeCmd == "" {
+				return newError(ErrManagerUnsupported, "%s does not support snapshotting installed dependencies", pm.Name)
+			}
+			args, snapshotFile = takeValueFlag(args, "--output")
+			args = append(strings.Fields(pm.FreezeCmd), args[1:]...)
+			if snapshotFile != "" && !dryRun {
+				file, err := os.Create(snapshotFile)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", snapshotFile, err)
+				}
+				defer file.Close()
+				stdout = file
+			}
+		case "which":
+			if len(args) != 2 {
+				return usageError("uni which <package>")
+			}
+			switch {
+			case slices.Contains([]string{"NPM", "PNPM", "Yarn", "Bun"}, pm.Name):
+				return whichNodePackage(args[1])
+			case pm.WhichCmd == "":
+				return newError(ErrManagerUnsupported, "%s can't locate an installed package", pm.Name)
+			}
+			args = append(strings.Fields(pm.WhichCmd), args[1])
+		case "outdated":
+			if pm.OutdatedCmd == "" {
+				return newError(ErrManagerUnsupported, "%s can't list outdated dependencies", pm.Name)
+			}
+			args = append(strings.Fields(pm.OutdatedCmd), args[1:]...)
+		case "list", "ls":
+			var tree bool
+			var depth string
+			args, tree = takeBoolFlag(args, "--tree")
+			args, depth = takeValueFlag(args, "--depth")
+			if depth != "" {
+				if n, err := strconv.Atoi(depth); err != nil || n < 0 {
+					return newError(ErrUsage, "--depth expects a non-negative number, got '%s'", depth)
+				}
+			}
+			listArgs := strings.Fields(pm.ListCmd)
+			if len(listArgs) == 0 {
+				listArgs = args[:1]
+			}
+			switch {
+			case (tree || depth != "") && pm.TreeCmd == "":
+				color.Yellow("Note: %s can't show a dependency tree; showing a flat list.", pm.Name)
+			case tree || depth != "":
+				listArgs = strings.Fields(pm.TreeCmd)
+				if depth != "" {
+					if pm.DepthFlag == "" {
+						color.Yellow("Warning: %s can't limit the tree depth; --depth has no effect.", pm.Name)
+					} else {
+						// An explicit depth replaces the default one in TreeCmd.
+						listArgs = slices.DeleteFunc(listArgs, func(arg string) bool { return strings.HasPrefix(arg, pm.DepthFlag) })
+						listArgs = append(listArgs, pm.DepthFlag+depth)
+					}
+				}
+			}
+			args = append(listArgs, args[1:]...)
+		case "reinstall":
+			var force bool
+			args, force = takeBoolFlag(args, "--force")
+			if len(args) == 1 {
+				return usageError("uni reinstall <package...> [--force]")
+			}
+			if pm.ReinstallCmd == "" {
+				return reinstallSequentially(pm, args[1:], force)
+			}
+			args = append(strings.Fields(pm.ReinstallCmd), args[1:]...)
+		case "run":
+			// `uni run test -- --watch` always separates the script's own
+			// arguments with `--`. npm needs it to stop parsing its own
+			// flags, but pnpm, Yarn and Bun already forward everything after
+			// the script name and would hand the `--` on to the script.
+			switch pm.Name {
+			case "PNPM", "Yarn", "Bun":
+				if i := slices.Index(args, "--"); i > 1 {
+					args = slices.Delete(slices.Clone(args), i, i+1)
+				}
+			}
+		}
+	}
+	if preview {
+		return previewInstall(pm, env, args)
+	}
+	if dryRun {
+		color.HiBlack("+ %s", commandLine(pm.Executable, args))
+		color.Yellow("Dry run: command not executed.")
+		return nil
+	}
+	cmd, ctx, cancel := newCliCommand(env, pm.Executable, args...)
+	defer cancel()
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	// --summary still streams the output, keeping a copy to parse.
+	var captured bytes.Buffer
+	if summary {
+		cmd.Stdout = io.MultiWriter(stdout, &captured)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &captured)
+	}
+	started := time.Now()
+	color.HiBlack("+ %s", commandLine(pm.Executable, args))
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return newError(ErrCommandFailed, "%s %s timed out after %s and was killed", pm.Executable, strings.Join(args, " "), cmdTimeout)
+		}
+		return &uniError{Category: ErrCommandFailed, Err: fmt.Errorf("%s %s: %w", pm.Executable, strings.Join(args, " "), err), Quiet: true}
+	}
+	if snapshotFile != "" && !dryRun {
+		color.Green("Wrote dependency snapshot to %s.", snapshotFile)
+	}
+	if summary {
+		printInstallSummary(pm, captured.String(), time.Since(started))
+	}
+	return nil
+}
+
+// installSummaryPatterns pick package counts out of each manager's install
+// output, keyed by manager name. Each pattern's first group is the count
+// for its label; pnpm reports `Packages: +12 -3` with signed counts.
+var installSummaryPatterns = map[string][]struct {
+	Label   string
+	Pattern *regexp.Regexp
+}{
+	"NPM": {
+		{"added", regexp.MustCompile(`added (\d+) packages?`)},
+		{"changed", regexp.MustCompile(`changed (\d+) packages?`)},
+		{"removed", regexp.MustCompile(`removed (\d+) packages?`)},
+	},
+	"PNPM": {
+		{"added", regexp.MustCompile(`Packages: .*\+(\d+)`)},
+		{"removed", regexp.MustCompile(`Packages: .*-(\d+)`)},
+	},
+	"Yarn":     {{"added", regexp.MustCompile(`Saved (\d+) new dependenc`)}},
+	"Bun":      {{"installed", regexp.MustCompile(`(\d+) packages? installed`)}},
+	"RubyGems": {{"installed", regexp.MustCompile(`(\d+) gems? installed`)}},
+}
+
+// printInstallSummary reports what an install changed, parsed from the
+// output it streamed, and how long it took. The human-readable output is
+// parsed rather than asking for JSON so the install still streams as usual.
+func printInstallSummary(pm PackageManagerInfo, output string, elapsed time.Duration) {
+	var counts []string
+	for _, p := range installSummaryPatterns[pm.Name] {
+		if m := p.Pattern.FindStringSubmatch(output); m != nil {
+			counts = append(counts, m[1]+" "+p.Label)
+		}
+	}
+	switch pm.Name {
+	case "Pip":
+		// `Successfully installed a-1.0 b-2.0` names one package per field.
+		for _, line := range strings.Split(output, "\n") {
+			if pkgs, ok := strings.CutPrefix(strings.TrimSpace(line), "Successfully installed "); ok {
+				counts = append(counts, fmt.Sprintf("%d installed", len(strings.Fields(pkgs))))
+			}
+		}
+	case "Bundler":
+		if n := strings.Count("\n"+output, "\nInstalling "); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d installed", n))
+		}
+	}
+	elapsedText := elapsed.Round(100 * time.Millisecond).String()
+	if len(counts) == 0 {
+		color.Cyan("📦 Summary: finished in %s.", elapsedText)
+		return
+	}
+	color.Cyan("📦 Summary: %s in %s.", strings.Join(counts, ", "), elapsedText)
+}
+
+// installManager offers to run pm's installation command when its hint is
+// one ("Run: ..."), and reports whether pm is usable afterwards. It only runs
+// after the user confirms, or unattended with --auto-install-manager.
+func installManager(pm PackageManagerInfo) bool {
+	command, ok := strings.CutPrefix(pm.InstallationHint, "Run: ")
+	if !ok || dryRun {
+		return false
+	}
+	if !autoInstallManager {
+		if !interactive() || !confirm(fmt.Sprintf("%s isn't installed. Run '%s' to install it?", pm.Name, command)) {
+			return false
+		}
+	}
+	color.Cyan("📥 Installing %s: %s", pm.Name, command)
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/c"
+	}
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		color.Red("Installing %s failed: %v", pm.Name, err)
+		return false
+	}
+	forgetLookPath(pm.Executable)
+	_, err := lookPathCached(pm.Executable)
+	return err == nil
+}
+
+// readPackageList reads newline-separated package names, skipping blank
+// lines and `#` comments. source names the input in error messages.
+func readPackageList(r io.Reader, source string) ([]string, error) {
+	var pkgs []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		pkg := strings.TrimSpace(scanner.Text())
+		if pkg == "" || strings.HasPrefix(pkg, "#") {
+			continue
+		}
+		if strings.HasPrefix(pkg, "-") || strings.ContainsAny(pkg, " \t") {
+			return nil, fmt.Errorf("%s:%d: '%s' is not a valid package name", source, line, pkg)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", source, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages listed on %s", source)
+	}
+	return pkgs, nil
+}
+
+// lookPathResult is a memoized exec.LookPath outcome.
+type lookPathResult struct {
+	path string
+	err  error
+}
+
+var (
+	lookPathMu    sync.Mutex
+	lookPathCache = map[string]lookPathResult{}
+)
+
+// lookPathCached is exec.LookPath, remembering results for the rest of the
+// invocation so commands touching many managers scan PATH once per
+// executable.
+func lookPathCached(file string) (string, error) {
+	lookPathMu.Lock()
+	defer lookPathMu.Unlock()
+	if result, ok := lookPathCache[file]; ok {
+		return result.path, result.err
+	}
+	path, err := exec.LookPath(file)
+	lookPathCache[file] = lookPathResult{path, err}
+	return path, err
+}
+
+// forgetLookPath drops the cached lookup for file, e.g. after installing it.
+func forgetLookPath(file string) {
+	lookPathMu.Lock()
+	defer lookPathMu.Unlock()
+	delete(lookPathCache, file)
+}
+
+// stdinIsTerminal reports whether uni's stdin is a terminal. Tests stub it.
+var stdinIsTerminal = func() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// newCliCommand builds a child process that runs in the working directory
+// and is killed once --cmd-timeout elapses. In a mise project it runs
+// through `mise exec`. env adds KEY=VAL pairs for this command only, after
+// those from --env. Callers must call cancel after the command finishes and
+// can check ctx to tell whether it timed out.
+func newCliCommand(env []string, name string, args ...string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	name, args = miseCommand(name, args)
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if cmdTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cmdTimeout)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	// A child in a process group of its own leaves the terminal's foreground
+	// group: reading a prompt stops it with SIGTTIN and Ctrl-C never reaches
+	// it. On a terminal the timeout therefore only kills the child itself.
+	if cmdTimeout > 0 && !stdinIsTerminal() {
+		startInProcessGroup(cmd)
+		cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	}
+	cmd.Dir = workDir
+	if len(extraEnv) > 0 || len(env) > 0 {
+		cmd.Env = slices.Concat(os.Environ(), extraEnv, env)
+	}
+	return cmd, ctx, cancel
+}
+
+// previewInstall runs the manager's dry-run install and summarizes which
+// packages would be added, changed or removed. Managers without a dry-run
+// mode only get the command printed.
+func previewInstall(pm PackageManagerInfo, env []string, args []string) error {
+	if pm.DryRunFlag == "" {
+		color.Yellow("%s has no dry-run mode, showing the command only.", pm.Name)
+		color.HiBlack("+ %s", commandLine(pm.Executable, args))
+		return nil
+	}
+	args = append(args, pm.DryRunFlag)
+	cmd, _, cancel := newCliCommand(env, pm.Executable, args...)
+	defer cancel()
+	color.HiBlack("+ %s", commandLine(pm.Executable, args))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		os.Stderr.Write(output)
+		return &uniError{Category: ErrCommandFailed, Err: err, Quiet: true}
+	}
+
+	// npm reports planned changes as `add <name> <version>`,
+	// `change <name> <from> => <to>` and `remove <name> <version>`.
+	var added, changed, removed int
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		details := strings.Join(fields[1:], " ")
+		switch fields[0] {
+		case "add":
+			added++
+			color.Green("  + %s", details)
+		case "change":
+			changed++
+			color.Yellow("  ~ %s", details)
+		case "remove":
+			removed++
+			color.Red("  - %s", details)
+		}
+	}
+	if added+changed+removed == 0 {
+		fmt.Print(string(output))
+		return nil
+	}
+	color.Cyan("Preview: %d to add, %d to change, %d to remove.", added, changed, removed)
+	return nil
+}
+
+// reinstallSequentially emulates a reinstall for managers without a native
+// command. The install step is skipped when the uninstall fails, unless
+// force is set.
+func reinstallSequentially(pm PackageManagerInfo, pkgs []string, force bool) error {
+	if err := runCliCommand(pm, append([]string{"uninstall"}, pkgs...)); err != nil {
+		if !force {
+			color.Red("Uninstall failed, not reinstalling %s. Pass --force to install anyway.", strings.Join(pkgs, " "))
+			return err
+		}
+		color.Yellow("Uninstall failed, installing anyway because of --force.")
+	}
+	return runCliCommand(pm, append([]string{"install"}, pkgs...))
+}
+
+// gitHosts are forges whose plain https URLs are taken to be git
+// repositories; other hosts need a git+ prefix, a git@ address or .git.
+var gitHosts = []string{"github.com", "gitlab.com", "bitbucket.org", "codeberg.org"}
+
+// parseGitURL recognizes an install argument naming a git repository and
+// returns its URL without any git+ prefix, and the ref after a trailing
+// `#`, if any.
+func parseGitURL(arg string) (repo, ref string, ok bool) {
+	raw := strings.TrimPrefix(arg, "git+")
+	raw, ref, _ = strings.Cut(raw, "#")
+	switch {
+	case strings.HasPrefix(arg, "git+"), strings.HasPrefix(raw, "git://"), strings.HasPrefix(raw, "git@"):
+	case strings.HasPrefix(raw, "https://") || strings.HasPrefix(raw, "ssh://"):
+		u, err := url.Parse(raw)
+		if err != nil || !slices.Contains(gitHosts, u.Hostname()) && !strings.HasSuffix(u.Path, ".git") {
+			return "", "", false
+		}
+	default:
+		return "", "", false
+	}
+	// scp-style git@host:path addresses become ssh:// URLs, which every
+	// manager's git syntax accepts.
+	if rest, found := strings.CutPrefix(raw, "git@"); found {
+		host, path, _ := strings.Cut(rest, ":")
+		raw = "ssh://git@" + host + "/" + path
+	}
+	return raw, ref, true
+}
+
+// gitInstallArgs rewrites git URLs among the install arguments into the
+// syntax pm uses for installing from git. Managers without git installs get
+// a warning and the argument unchanged.
+func gitInstallArgs(pm PackageManagerInfo, pkgs []string) []string {
+	var out []string
+	for _, arg := range pkgs {
+		repo, ref, ok := parseGitURL(arg)
+		if !ok {
+			out = append(out, arg)
+			continue
+		}
+		switch pm.Name {
+		case "NPM", "PNPM", "Yarn", "Bun":
+			spec := "git+" + repo
+			if ref != "" {
+				spec += "#" + ref
+			}
+			out = append(out, spec)
+		case "Pip", "uv", "Poetry", "Pipx":
+			spec := "git+" + repo
+			if ref != "" {
+				spec += "@" + ref
+			}
+			out = append(out, spec)
+		case "Go":
+			u, err := url.Parse(repo)
+			if err != nil {
+				out = append(out, arg)
+				continue
+			}
+			module := u.Hostname() + strings.TrimSuffix(u.Path, ".git")
+			if ref != "" {
+				module += "@" + ref
+			}
+			out = append(out, module)
+		case "Bundler":
+			name := strings.TrimSuffix(filepath.Base(repo), ".git")
+			out = append(out, name, "--git="+repo)
+			if ref != "" {
+				out = append(out, "--ref="+ref)
+			}
+		case "Swift":
+			out = append(out, repo)
+			if ref != "" {
+				out = append(out, "--branch", ref)
+			}
+		default:
+			color.Yellow("Warning: %s can't install from a git URL; passing '%s' through unchanged.", pm.Name, arg)
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+// whichNodePackage reports the node_modules directory Node resolves name to
+// from the project root, and the version installed there. Resolution walks
+// up the parent directories like require does.
+func whichNodePackage(name string) error {
+	root, err := filepath.Abs(projectPath("."))
+	if err != nil {
+		return err
+	}
+	dir := root
+	for {
+		pkgDir := filepath.Join(dir, "node_modules", name)
+		if data, err := readFileTimed(filepath.Join(pkgDir, "package.json")); err == nil {
+			var manifest struct {
+				Version string `json:"version"`
+			}
+			json.Unmarshal(data, &manifest)
+			if real, err := filepath.EvalSymlinks(pkgDir); err == nil && real != pkgDir {
+				// pnpm and workspaces link packages in from elsewhere.
+				fmt.Printf("%s@%s: %s -> %s\n", name, manifest.Version, pkgDir, real)
+			} else {
+				fmt.Printf("%s@%s: %s\n", name, manifest.Version, pkgDir)
+			}
+			return nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return newError(ErrCommandFailed, "%s is not installed in any node_modules from %s up", name, root)
+		}
+		dir = parent
+	}
+}
+
+// goInstallArgs turns `go get` into `go install` when every package looks
+// like a command rather than a module dependency: its path has a cmd
+// element, or it names a version and there's no go.mod to add it to.
+// Commands installed outside a module get @latest when unversioned, since
+// go install needs a version there.
+func goInstallArgs(args []string) []string {
+	_, err := os.Stat(projectPath("go.mod"))
+	inModule := err == nil
+	var pkgs []int
+	for i, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		path, _, versioned := strings.Cut(arg, "@")
+		isCmd := strings.Contains("/"+path+"/", "/cmd/")
+		if !isCmd && (inModule || !versioned) {
+			return args
+		}
+		pkgs = append(pkgs, i+1)
+	}
+	if len(pkgs) == 0 {
+		return args
+	}
+	args = slices.Clone(args)
+	args[0] = "install"
+	for _, i := range pkgs {
+		if !inModule && !strings.Contains(args[i], "@") {
+			args[i] += "@latest"
+		}
+	}
+	return args
+}
+
+// nodePrereleaseArgs asks for the `next` dist-tag, where packages publish
+// their pre-releases by convention, for each registry package that doesn't
+// already name a version or tag. npm-family installs have no pre-release
+// flag of their own. Only packages whose next tag exists and is newer than
+// latest are rewritten; the rest install latest as usual.
+func nodePrereleaseArgs(args []string) []string {
+	args = slices.Clone(args)
+	var indexes []int
+	for i, arg := range args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "-"), strings.Contains(arg, ":"), strings.HasPrefix(arg, "."), strings.HasPrefix(arg, "/"):
+			// Flags, git, file and alias specs, and local paths.
+		case strings.Contains(strings.TrimPrefix(arg, "@"), "@"):
+		default:
+			indexes = append(indexes, i+1)
+		}
+	}
+	if len(indexes) == 0 {
+		return args
+	}
+	if offline {
+		color.Yellow("Warning: can't look up next tags offline; --include-prerelease has no effect.")
+		return args
+	}
+	registry, token := npmRegistryConfig()
+	tags, errs := fetchDetails(indexes, func(i int) (map[string]string, error) {
+		return fetchNPMDistTags(registry, token, args[i])
+	})
+	for n, i := range indexes {
+		next, latest := tags[n]["next"], tags[n]["latest"]
+		switch {
+		case errs[n] != nil:
+			color.Yellow("Warning: could not look up the dist-tags of %s (%v); installing latest.", args[i], errs[n])
+		case next == "" || !newerVersion(next, latest):
+			color.Yellow("%s has no pre-release newer than latest; installing latest.", args[i])
+		default:
+			logVerbose("%s: next is %s, latest %s", args[i], next, latest)
+			args[i] += "@next"
+		}
+	}
+	return args
+}
+
+// installSpecArgs builds pm's install command for pkgs, translating git URLs
+// and version pins into the manager's own syntax.
+func installSpecArgs(pm PackageManagerInfo, pkgs []string) []string {
+	args := append(strings.Fields(pm.InstallCmd), gitInstallArgs(pm, pkgs)...)
+	switch pm.Name {
+	case "Go":
+		args = goInstallArgs(args)
+	case "Pip", "uv", "Pipx":
+		args = pythonRequirementArgs(args)
+	}
+	return args
+}
+
+// pythonPinnedSpec matches the `name@version` pin other managers use, with
+// optional extras, e.g. `requests[socks]@2.31.0`. PEP 508 gives `@` to
+// direct URL references, so a version must follow it directly.
+var pythonPinnedSpec = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*(?:\[[^\]]*\])?)@([0-9][A-Za-z0-9.+!*-]*)$`)
+
+// pythonRequirementArgs rewrites `name@version` pins into the `name==version`
+// requirement pip, uv and pipx understand. Everything else, including
+// extras, environment markers and specifiers such as `django>=4,<5`, is
+// passed through as written. Poetry reads `name@version` natively, so it
+// isn't rewritten.
+func pythonRequirementArgs(args []string) []string {
+	args = slices.Clone(args)
+	for i, arg := range args[1:] {
+		if m := pythonPinnedSpec.FindStringSubmatch(arg); m != nil {
+			args[i+1] = m[1] + "==" + m[2]
+			logVerbose("rewrote %s to the requirement %s", arg, args[i+1])
+		}
+	}
+	return args
+}
+
+// takeBoolFlag removes every occurrence of the given flags from args and
+// reports whether any of them was present.
+func takeBoolFlag(args []string, names ...string) ([]string, bool) {
+	var found bool
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if slices.Contains(names, arg) {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// takeValueFlag removes `name=value` flags from args and returns the last
+// value given, or "" when the flag is absent.
+func takeValueFlag(args []string, name string) ([]string, string) {
+	var value string
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			value = v
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, value
+}
+
+// detection is the outcome of detectPackageManager: the chosen manager and
+// what led to it.
+type detection struct {
+	Key     string             `json:"key"`
+	Manager PackageManagerInfo `json:"-"`
+	// Source is what triggered the choice: flag, env, detect-from, config,
+	// corepack, lockfile, metadata or fallback.
+	Source string `json:"source"`
+	// File is the lock, metadata or config file involved, if any.
+	File string `json:"file,omitempty"`
+	// Version is the manager version the project pins, if any.
+	Version string `json:"version,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// pkgSource names where the requested manager came from, --pkg or UNI_PKG,
+// for detection reasons.
+var pkgSource = "UNI_PKG"
+
+func detectPackageManager(specifiedManager string) (PackageManagerInfo, error) {
+	result, err := reportDetection(specifiedManager)
+	return result.Manager, err
+}
+
+// reportDetection resolves the manager and tells the user why it was chosen.
+func reportDetection(specifiedManager string) (detection, error) {
+	result, err := resolvePackageManager(specifiedManager)
+	if err != nil {
+		return detection{}, err
+	}
+	// A manager requested by name needs no explanation, unlike one picked
+	// from an ecosystem alias or the project files.
+	if _, alias := managerAliases[specifiedManager]; alias || (result.Source != "flag" && result.Source != "env") {
+		color.Yellow("%s", result.Reason)
+	}
+	recordStats(func(stats *usageStats) { stats.Managers[result.Key]++ })
+	return result, nil
+}
+
+var (
+	variantMu    sync.Mutex
+	variantCache = map[string]string{}
+)
+
+// managerVariant returns the flavor of the installed manager key, "" when
+// its version can't be read. The version is probed the first time it's
+// asked for and remembered for the rest of the invocation, like
+// lookPathCached, so only commands that run the manager pay for it.
+func managerVariant(key string) string {
+	variantMu.Lock()
+	defer variantMu.Unlock()
+	if variant, ok := variantCache[key]; ok {
+		return variant
+	}
+	variant := ""
+	if probe := probeManager(key); probe.Err == nil && probe.Version != "" {
+		switch key {
+		case "yarn":
+			variant = "berry"
+			if strings.HasPrefix(probe.Version, "1.") {
+				variant = "classic"
+			}
+		}
+	}
+	variantCache[key] = variant
+	return variant
+}
+
+// resolveVariant adjusts pm's commands to the installed version where their
+// syntax differs between major releases.
+func resolveVariant(pm PackageManagerInfo) PackageManagerInfo {
+	switch pm.Name {
+	case "Yarn":
+		pm.Variant = managerVariant("yarn")
+		if pm.Variant != "classic" {
+			return pm
+		}
+		// Yarn classic has no dlx, dedupe or lockfile-only mode, but does
+		// have outdated and lockfile-free installs, and spells immutable
+		// installs and dependency listings differently.
+		pm.FrozenCmd = "install --frozen-lockfile"
+		pm.ExecutionCmd = ""
+		pm.DedupeCmd = ""
+		pm.ManifestOnlyFlag = ""
+		pm.IgnoreScriptsFlag = "--ignore-scripts"
+		pm.PreferOfflineFlag = "--prefer-offline"
+		pm.NoLockfileFlag = "--no-lockfile"
+		pm.OutdatedCmd = "outdated"
+		pm.ListCmd = "list --depth=0"
+		pm.TreeCmd = "list"
+		pm.DepthFlag = "--depth="
+	}
+	return pm
+}
+
+// resolvePackageManager works out which manager to use without reporting
+// the choice, so callers such as `uni detect` can explain it.
+func resolvePackageManager(specifiedManager string) (detection, error) {
+	if specifiedManager != "" {
+		source := "env"
+		if pkgSource == "--pkg" {
+			source = "flag"
+		}
+		if family, ok := managerAliases[specifiedManager]; ok {
+			result := detectFamilyManager(specifiedManager, family)
+			result.Source = source
+			return result, nil
+		}
+		if pm, ok := supportedManagers[specifiedManager]; ok {
+			return detection{Key: specifiedManager, Manager: pm, Source: source, Reason: fmt.Sprintf("%s requested %s.", pkgSource, pm.Name)}, nil
+		}
+		return detection{}, newError(ErrManagerUnsupported, "specified package manager '%s' is not supported", specifiedManager)
+	}
+	if detectFrom != "" {
+		for _, key := range orderedManagerKeys() {
+			if pm := supportedManagers[key]; slices.Contains(pm.LockFiles, detectFrom) {
+				return detection{Key: key, Manager: pm, Source: "detect-from", File: detectFrom, Reason: fmt.Sprintf("Using %s, which owns '%s' (--detect-from).", pm.Name, detectFrom)}, nil
+			}
+		}
+		return detection{}, newError(ErrManagerUnsupported, "no supported package manager uses a lock file named '%s'", detectFrom)
+	}
+	config, err := loadEffectiveConfig()
+	if err != nil {
+		return detection{}, &uniError{Category: ErrConfig, Err: err}
+	}
+	if pm, ok := supportedManagers[config.Manager]; ok {
+		if _, err := lookPathCached(pm.Executable); err != nil {
+			if strict {
+				return detection{}, &uniError{
+					Category: ErrManagerNotInstalled,
+					Err:      fmt.Errorf("'%s' sets %s, which is not installed or not in your PATH", config.ManagerFile, pm.Name),
+					Hint:     pm.InstallationHint,
+				}
+			}
+			color.Yellow("Warning: '%s' sets %s, but %s isn't installed; detecting from project files instead.", config.ManagerFile, pm.Name, pm.Executable)
+		} else {
+			return detection{Key: config.Manager, Manager: pm, Source: "config", File: config.ManagerFile, Reason: fmt.Sprintf("Found '%s' config file, using %s.", config.ManagerFile, pm.Name)}, nil
+		}
+	}
+	for _, key := range config.Prefer {
+		if _, ok := supportedManagers[key]; !ok {
+			color.Yellow("Ignoring unknown package manager '%s' in the %s prefer list.", key, config.PreferFile)
+		}
+	}
+
+	// A corepack pin is the project's own statement of its node manager.
+	if result, ok := detectCorepack(); ok {
+		return result, nil
+	}
+
+	// Check for lock files first
+	var candidates []string
+	foundLockFiles := map[string]string{}
+	for _, key := range orderedManagerKeys() {
+		pm := supportedManagers[key]
+		for _, lockFile := range pm.LockFiles {
+			if _, err := statProjectFile(lockFile); err == nil {
+				candidates = append(candidates, key)
+				foundLockFiles[key] = lockFile
+				break
+			}
+		}
+		if _, found := foundLockFiles[key]; !found && key == "pod" {
+			if _, err := statProjectFile("Podfile"); err == nil {
+				candidates = append(candidates, key)
+				foundLockFiles[key] = "Podfile"
+			}
+		}
+	}
+	if lockFile, ok := foundLockFiles["bun"]; ok {
+		logBunLockfile(lockFile)
+	}
+	if len(candidates) > 1 {
+		for _, key := range config.Prefer {
+			if slices.Contains(candidates, key) {
+				pm := supportedManagers[key]
+				return detection{Key: key, Manager: pm, Source: "lockfile", File: foundLockFiles[key], Reason: fmt.Sprintf("Found lock files for %s, preferring %s per '%s'.", strings.Join(candidates, ", "), pm.Name, config.PreferFile)}, nil
+			}
+		}
+	}
+	if len(candidates) > 1 {
+		if key := toolVersionsManager(candidates); key != "" {
+			pm := supportedManagers[key]
+			return detection{Key: key, Manager: pm, Source: "lockfile", File: foundLockFiles[key], Reason: fmt.Sprintf("Found lock files for %s, using %s from '%s'.", strings.Join(candidates, ", "), pm.Name, toolVersionsFile)}, nil
+		}
+	}
+	if len(candidates) > 0 {
+		key := candidates[0]
+		pm := supportedManagers[key]
+		return detection{Key: key, Manager: pm, Source: "lockfile", File: foundLockFiles[key], Reason: fmt.Sprintf("Found '%s' lock file, using %s.", foundLockFiles[key], pm.Name)}, nil
+	}
+
+	for _, key := range orderedManagerKeys() {
+		pm := supportedManagers[key]
+		// Check for metadata files like package.json, Podfile, etc.
+		for _, metaFile := range pm.MetadataFiles {
+			if metaFile == "pyproject.toml" {
+				if key, reason := detectPyproject(); key != "" {
+					pm := supportedManagers[key]
+					return detection{Key: key, Manager: pm, Source: "metadata", File: metaFile, Reason: fmt.Sprintf("Found '%s' %s, using %s.", metaFile, reason, pm.Name)}, nil
+				}
+			}
+			if _, err := statProjectFile(metaFile); err == nil {
+				if metaFile == "package.json" {
+					if nodeKey := toolVersionsManager(managerAliases["node"]); nodeKey != "" && nodeKey != key {
+						pm := supportedManagers[nodeKey]
+						return detection{Key: nodeKey, Manager: pm, Source: "metadata", File: metaFile, Reason: fmt.Sprintf("Found '%s' metadata file, using %s from '%s'.", metaFile, pm.Name, toolVersionsFile)}, nil
+					}
+				}
+				return detection{Key: key, Manager: pm, Source: "metadata", File: metaFile, Reason: fmt.Sprintf("Found '%s' metadata file, using %s.", metaFile, pm.Name)}, nil
+			}
+		}
+	}
+
+	key := "pkgx"
+	if _, err := lookPathCached("brew"); err == nil {
+		key = "brew"
+	}
+	return detection{Key: key, Manager: supportedManagers[key], Source: "fallback", Reason: "No project file detected, falling back to system package manager."}, nil
+}
+
+// detectPyproject picks the Python manager a pyproject.toml is written for
+// from its [tool.*] tables and build backend. It returns "" when there is no
+// pyproject.toml, and pip for one that names no specific tool.
+func detectPyproject() (key, reason string) {
+	data, err := readProjectFile("pyproject.toml")
+	if err != nil {
+		return "", ""
+	}
+	key, reason = "pip", "without tool-specific settings"
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[tool.poetry"), strings.HasPrefix(line, "build-backend") && strings.Contains(line, "poetry"):
+			return "poetry", "configured for Poetry"
+		case strings.HasPrefix(line, "[tool.uv"):
+			key, reason = "uv", "with [tool.uv] settings"
+		}
+	}
+	return key, reason
+}
+
+// logBunLockfile notes in verbose mode which of Bun's lockfile formats a
+// project uses. bun.lock, the text format Bun writes since 1.2, is listed
+// first in LockFiles so it wins while a project still has both.
+func logBunLockfile(found string) {
+	if found == "bun.lockb" {
+		logVerbose("found bun.lockb, the binary lockfile written by Bun before 1.2")
+		return
+	}
+	if _, err := statProjectFile("bun.lockb"); err == nil {
+		logVerbose("found both bun.lock and bun.lockb; using bun.lock, which newer Bun reads first")
+	} else {
+		logVerbose("found bun.lock, the text lockfile written by Bun 1.2 and later")
+	}
+}
+
+// detectCorepack reads the packageManager field corepack uses to pin a
+// node manager, e.g. "pnpm@8.15.4+sha512.…", from package.json.
+func detectCorepack() (detection, bool) {
+	data, err := readProjectFile("package.json")
+	if err != nil {
+		return detection{}, false
+	}
+	var manifest struct {
+		PackageManager string `json:"packageManager"`
+	}
+	if json.Unmarshal(data, &manifest) != nil || manifest.PackageManager == "" {
+		return detection{}, false
+	}
+	key, version, _ := strings.Cut(manifest.PackageManager, "@")
+	version, _, _ = strings.Cut(version, "+")
+	pm, ok := supportedManagers[key]
+	if !ok || !slices.Contains(managerAliases["node"], key) {
+		color.Yellow("Warning: package.json's packageManager '%s' isn't a node manager uni supports, ignoring it.", manifest.PackageManager)
+		return detection{}, false
+	}
+	reason := fmt.Sprintf("Found packageManager in package.json, using %s.", pm.Name)
+	if version != "" {
+		reason = fmt.Sprintf("Found packageManager in package.json, using %s %s.", pm.Name, version)
+	}
+	return detection{Key: key, Manager: pm, Source: "corepack", File: "package.json", Version: version, Reason: reason}, true
+}
+
+// toolVersionsFile is asdf's list of the tools a project expects.
+const toolVersionsFile = ".tool-versions"
+
+// toolVersionRuntimes maps asdf runtime plugins to the manager they bring
+// along, for .tool-versions files that pin a runtime but no manager. asdf's
+// Node.js plugin is named nodejs; node is accepted too.
+var toolVersionRuntimes = map[string]string{"nodejs": "npm", "node": "npm"}
+
+// toolVersionsManager returns the first of keys that .tool-versions pins,
+// or "" when it pins none or doesn't exist. Lines are `<tool> <version>...`
+// and asdf's plugins for pnpm, yarn, bun, poetry and uv use the same names
+// as uni, so the tool name is compared directly. A pinned manager wins over
+// one implied by a runtime in toolVersionRuntimes. asdf itself is never run.
+func toolVersionsManager(keys []string) string {
+	data, err := readProjectFile(toolVersionsFile)
+	if err != nil {
+		return ""
+	}
+	runtimeKey := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(stripConfigComment(line))
+		if len(fields) == 0 {
+			continue
+		}
+		if slices.Contains(keys, fields[0]) {
+			return fields[0]
+		}
+		if key, ok := toolVersionRuntimes[fields[0]]; ok && runtimeKey == "" && slices.Contains(keys, key) {
+			runtimeKey = key
+		}
+	}
+	return runtimeKey
+}
+
+// managerFamily returns the managerAliases ecosystem pm belongs to, or ""
+// for managers outside every family.
+func managerFamily(pm PackageManagerInfo) string {
+	for family, keys := range managerAliases {
+		for _, key := range keys {
+			if supportedManagers[key].Name == pm.Name {
+				return family
+			}
+		}
+	}
+	return ""
+}
+
+// detectFamilyManager picks the manager for an ecosystem alias from the lock
+// and metadata files of its family, falling back to the family's default.
+func detectFamilyManager(alias string, family []string) detection {
+	if result, ok := detectCorepack(); ok && slices.Contains(family, result.Key) {
+		result.Reason = strings.TrimSuffix(result.Reason, ".") + " for " + alias + "."
+		return result
+	}
+	for _, files := range []func(PackageManagerInfo) []string{
+		func(pm PackageManagerInfo) []string { return pm.LockFiles },
+		func(pm PackageManagerInfo) []string { return pm.MetadataFiles },
+	} {
+		for _, key := range family {
+			pm := supportedManagers[key]
+			for _, file := range files(pm) {
+				if file == "pyproject.toml" {
+					if pyKey, reason := detectPyproject(); slices.Contains(family, pyKey) {
+						pm := supportedManagers[pyKey]
+						return detection{Key: pyKey, Manager: pm, File: file, Reason: fmt.Sprintf("Found '%s' %s, using %s for %s.", file, reason, pm.Name, alias)}
+					}
+				}
+				if _, err := statProjectFile(file); err == nil {
+					if file == "package.json" {
+						if nodeKey := toolVersionsManager(family); nodeKey != "" && nodeKey != key {
+							pm := supportedManagers[nodeKey]
+							return detection{Key: nodeKey, Manager: pm, File: file, Reason: fmt.Sprintf("Found '%s', using %s from '%s' for %s.", file, pm.Name, toolVersionsFile, alias)}
+						}
+					}
+					return detection{Key: key, Manager: pm, File: file, Reason: fmt.Sprintf("Found '%s', using %s for %s.", file, pm.Name, alias)}
+				}
+			}
+		}
+	}
+	pm := supportedManagers[family[0]]
+	return detection{Key: family[0], Manager: pm, Reason: fmt.Sprintf("No %s project file detected, using %s.", alias, pm.Name)}
+}
+
+// orderedManagerKeys returns every supported manager key, built-in
+// managers first in detectionOrder and any others sorted after them.
+func orderedManagerKeys() []string {
+	keys := slices.Clone(detectionOrder)
+	var extra []string
+	for key := range supportedManagers {
+		if !slices.Contains(keys, key) {
+			extra = append(extra, key)
+		}
+	}
+	slices.Sort(extra)
+	return append(keys, extra...)
+}
+
+// handleDetect implements `uni detect`, explaining which manager would run
+// and why.
+func handleDetect(specifiedManager string) {
+	result, err := resolvePackageManager(specifiedManager)
+	if err != nil {
+		exitWithError(err)
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(struct {
+			detection
+			Name string `json:"name"`
+		}{result, result.Manager.Name}); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	fmt.Printf("%s %s (%s)\n", color.CyanString("Manager:"), result.Manager.Name, result.Key)
+	fmt.Printf("%s %s\n", color.CyanString("Source: "), result.Source)
+	if result.File != "" {
+		fmt.Printf("%s %s\n", color.CyanString("File:   "), result.File)
+	}
+	if result.Version != "" {
+		fmt.Printf("%s %s\n", color.CyanString("Version:"), result.Version)
+	}
+	fmt.Printf("%s %s\n", color.CyanString("Reason: "), result.Reason)
+}
+
+// setWorkDir validates path and makes it the directory uni operates on.
+func setWorkDir(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("--cwd directory '%s' does not exist", path)
+		}
+		return fmt.Errorf("could not access --cwd directory '%s': %w", path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("--cwd path '%s' is not a directory", path)
+	}
+	workDir = path
+	return nil
+}
+
+// manifestFile is the name of the manifest chosen with --manifest, inside
+// workDir. It is empty when the manager's default manifest is used.
+var manifestFile string
+
+// setManifest validates path and makes its directory the working directory,
+// as --cwd would, remembering the file name for applyManifest.
+func setManifest(path string) error {
+	info, err := os.Stat(projectPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("--manifest file '%s' does not exist", path)
+		}
+		return fmt.Errorf("could not access --manifest file '%s': %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("--manifest path '%s' is a directory; use --cwd for directories", path)
+	}
+	workDir = filepath.Dir(projectPath(path))
+	manifestFile = filepath.Base(path)
+	return nil
+}
+
+// applyManifest points an install at the --manifest file where the manager
+// can read a manifest with a non-default name; pip's is handled with its
+// `-r` install. It returns the install args and any env pairs the command
+// needs, such as Bundler's BUNDLE_GEMFILE. Elsewhere the manifest only chose
+// the directory, which is reported when the name isn't one the manager reads.
+func applyManifest(pm PackageManagerInfo, args []string) ([]string, []string) {
+	if manifestFile == "" || slices.Contains(pm.MetadataFiles, manifestFile) {
+		return args, nil
+	}
+	switch pm.Name {
+	case "Pip":
+		return args, nil
+	case "Go":
+		// go install ignores the current module, so -modfile only applies to go get.
+		if args[0] == "get" {
+			return slices.Insert(args, 1, "-modfile="+manifestFile), nil
+		}
+		return args, nil
+	case "Bundler":
+		return args, []string{"BUNDLE_GEMFILE=" + projectPath(manifestFile)}
+	}
+	color.Yellow("Warning: %s always reads its default manifest; --manifest=%s only sets the directory.", pm.Name, manifestFile)
+	return args, nil
+}
+
+// projectPath resolves a project file name against the working directory.
+func projectPath(name string) string {
+	return filepath.Join(workDir, name)
+}
+
+// statTimeout bounds each project file check or read made during
+// detection. Filesystem calls can't be cancelled, so one stuck on an
+// unresponsive NFS or SMB mount is abandoned in its goroutine and the file
+// treated as missing. Tests shorten it.
+var statTimeout = 2 * time.Second
+
+// statFile and readFile are the filesystem calls project file checks make.
+// Tests replace them to simulate a stalled mount.
+var (
+	statFile = os.Stat
+	readFile = os.ReadFile
+)
+
+type statResult struct {
+	info os.FileInfo
+	data []byte
+	err  error
+}
+
+var (
+	statCacheMu  sync.Mutex
+	statCache    = map[string]statResult{}
+	slowStatWarn sync.Once
+)
+
+// statProjectFile is os.Stat for a project file during detection. Results,
+// including timeouts, are cached for the rest of the run, so detecting again,
+// as `outdated --all-managers` does per ecosystem, doesn't touch the
+// filesystem twice. Code that creates project files uses os.Stat directly.
+func statProjectFile(name string) (os.FileInfo, error) {
+	result := timedFileCall("stat", projectPath(name), func(path string) statResult {
+		info, err := statFile(path)
+		return statResult{info: info, err: err}
+	})
+	return result.info, result.err
+}
+
+// readProjectFile is os.ReadFile for a project file, with the timeout and
+// cache of statProjectFile.
+func readProjectFile(name string) ([]byte, error) {
+	return readFileTimed(projectPath(name))
+}
+
+// readFileTimed is readProjectFile for a path outside the project root, such
+// as a node_modules directory further up.
+func readFileTimed(path string) ([]byte, error) {
+	result := timedFileCall("read", path, func(path string) statResult {
+		data, err := readFile(path)
+		return statResult{data: data, err: err}
+	})
+	return result.data, result.err
+}
+
+// timedFileCall runs call on path unless its result for op is cached, giving
+// up after statTimeout.
+func timedFileCall(op, path string, call func(path string) statResult) statResult {
+	key := op + ":" + path
+	statCacheMu.Lock()
+	result, ok := statCache[key]
+	statCacheMu.Unlock()
+	if ok {
+		return result
+	}
+	done := make(chan statResult, 1)
+	go func() { done <- call(path) }()
+	select {
+	case result = <-done:
+	case <-time.After(statTimeout):
+		result = statResult{err: fmt.Errorf("%s %s: %w", op, path, os.ErrDeadlineExceeded)}
+		slowStatWarn.Do(func() {
+			color.Yellow("Warning: checking '%s' took over %s; treating slow project files as missing. Is the directory on a stalled network mount?", filepath.Base(path), statTimeout)
+		})
+		logVerbose("%s: %s timed out after %s", path, op, statTimeout)
+	}
+	statCacheMu.Lock()
+	statCache[key] = result
+	statCacheMu.Unlock()
+	return result
+}
+
+// doctorProbeTimeout bounds each `--version` probe run by `uni doctor`, so
+// one broken install can't stall the whole report.
+const doctorProbeTimeout = 3 * time.Second
+
+// doctorProbe is the outcome of checking one manager's installation.
+type doctorProbe struct {
+	Key     string
+	Path    string
+	Version string
+	Err     error
+}
+
+// handleDoctor reports which managers are installed and which version each
+// one reports. Probes run concurrently with output captured, and any probe
+// exceeding doctorProbeTimeout is reported as timed out.
+func handleDoctor() {
+	probes, _ := fetchDetails(orderedManagerKeys(), func(key string) (doctorProbe, error) {
+		return probeManager(key), nil
+	})
+
+	color.Cyan("🩺 Checking package managers...")
+	for _, probe := range probes {
+		pm := supportedManagers[probe.Key]
+		switch {
+		case probe.Path == "":
+			fmt.Printf("  %s %-12s %s\n", color.HiBlackString("-"), pm.Name, color.HiBlackString("not installed"))
+		case errors.Is(probe.Err, context.DeadlineExceeded):
+			fmt.Printf("  %s %-12s %s\n", color.RedString("✗"), pm.Name, color.RedString("timed out after %s (%s)", doctorProbeTimeout, probe.Path))
+		case probe.Err != nil:
+			fmt.Printf("  %s %-12s %s\n", color.RedString("✗"), pm.Name, color.RedString("%v (%s)", probe.Err, probe.Path))
+		default:
+			fmt.Printf("  %s %-12s %s %s\n", color.GreenString("✓"), pm.Name, probe.Version, color.HiBlackString("(%s)", probe.Path))
+		}
+	}
+}
+
+func probeManager(key string) doctorProbe {
+	pm := supportedManagers[key]
+	probe := doctorProbe{Key: key}
+	// In a mise project the pinned version is the one commands will run.
+	path, ok := miseWhich(pm.Executable)
+	if !ok {
+		var err error
+		if path, err = lookPathCached(pm.Executable); err != nil {
+			return probe
+		}
+	}
+	probe.Path = path
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorProbeTimeout)
+	defer cancel()
+	versionArgs := []string{"--version"}
+	if pm.VersionCmd != "" {
+		versionArgs = strings.Fields(pm.VersionCmd)
+	}
+	name, args := path, versionArgs
+	if miseExecutable() != "" {
+		name, args = miseCommand(pm.Executable, versionArgs)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Project settings such as Yarn's yarnPath can change the version.
+	cmd.Dir = workDir
+	startInProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	// Some managers prompt for input on first run; never let them block.
+	cmd.Stdin = nil
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			probe.Err = ctx.Err()
+		} else {
+			probe.Err = fmt.Errorf("%s failed: %v", strings.Join(versionArgs, " "), err)
+		}
+		return probe
+	}
+	probe.Version, _, _ = strings.Cut(strings.TrimSpace(output.String()), "\n")
+	return probe
+}
+
+// managerSummary describes a supported manager for `uni managers`.
+type managerSummary struct {
+	Key        string `json:"key"`
+	Name       string `json:"name"`
+	Executable string `json:"executable"`
+	Installed  bool   `json:"installed"`
+	SearchAPI  bool   `json:"searchApi"`
+}
+
+func handleManagers(asJSON bool) {
+	var summaries []managerSummary
+	for _, key := range orderedManagerKeys() {
+		pm := supportedManagers[key]
+		_, err := lookPathCached(pm.Executable)
+		summaries = append(summaries, managerSummary{
+			Key:        key,
+			Name:       pm.Name,
+			Executable: pm.Executable,
+			Installed:  err == nil,
+			SearchAPI:  pm.SearchAPISupport,
+		})
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summaries); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	fmt.Println(color.YellowString("%-8s %-12s %-12s %-10s %s", "KEY", "NAME", "EXECUTABLE", "INSTALLED", "SEARCH API"))
+	for _, summary := range summaries {
+		installed := color.RedString("%-10s", "no")
+		if summary.Installed {
+			installed = color.GreenString("%-10s", "yes")
+		}
+		searchAPI := "no"
+		if summary.SearchAPI {
+			searchAPI = "yes"
+		}
+		fmt.Printf("%-8s %-12s %-12s %s %s\n", summary.Key, summary.Name, summary.Executable, installed, searchAPI)
+	}
+}
+
+// bundleEntry is a single package listed in a `uni bundle` manifest.
+type bundleEntry struct {
+	Manager PackageManagerInfo
+	Package string
+}
+
+// handleBundle installs every package listed in a manifest. Each line holds
+// one package name, optionally prefixed with the manager to install it with
+// (`brew: git`); blank lines and `#` comments are ignored. A Brewfile is
+// handed to `brew bundle` as-is when Homebrew is the active manager.
+func handleBundle(pm PackageManagerInfo, file string, retryFailed bool) {
+	// A relative file is in the project, which --cwd may have moved.
+	path := file
+	if !filepath.IsAbs(path) {
+		path = projectPath(path)
+	}
+	if pm.Name == "Homebrew" && filepath.Base(file) == "Brewfile" {
+		absFile, err := filepath.Abs(path)
+		if err != nil {
+			exitWithError(err)
+		}
+		executeCliCommand(pm, []string{"bundle", "--file=" + absFile})
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		exitWithError(&uniError{Category: ErrUsage, Err: fmt.Errorf("failed to read %s: %w", file, err)})
+	}
+	var entries []bundleEntry
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry := bundleEntry{Manager: pm, Package: line}
+		if managerKey, pkg, ok := strings.Cut(line, ": "); ok {
+			linePM, supported := supportedManagers[strings.TrimSpace(managerKey)]
+			if !supported {
+				exitWithError(newError(ErrManagerUnsupported, "%s:%d: package manager '%s' is not supported", file, i+1, managerKey))
+			}
+			entry = bundleEntry{Manager: linePM, Package: strings.TrimSpace(pkg)}
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		color.Yellow("No packages listed in %s.", file)
+		return
+	}
+
+	if err := installBatch(entries, nil, retryFailed, file); err != nil {
+		exitWithError(err)
+	}
+}
+
+// installBatch installs entries one at a time, adding flags to each
+// install, so one bad package doesn't stop the rest. With retryFailed the
+// failures get a second attempt once everything else has been tried. It
+// ends with a report of the packages that still couldn't be installed.
+func installBatch(entries []bundleEntry, flags []string, retryFailed bool, source string) error {
+	installEach := func(entries []bundleEntry) []bundleEntry {
+		var failed []bundleEntry
+		for _, entry := range entries {
+			color.Cyan("📦 Installing %s with %s...", entry.Package, entry.Manager.Name)
+			if err := runCliCommand(entry.Manager, slices.Concat([]string{"install", entry.Package}, flags)); err != nil {
+				color.Red("Failed to install %s: %v", entry.Package, err)
+				failed = append(failed, entry)
+			}
+		}
+		return failed
+	}
+	failed := installEach(entries)
+	if retryFailed && len(failed) > 0 {
+		color.Cyan("🔁 Retrying %d failed packages...", len(failed))
+		failed = installEach(failed)
+	}
+	if len(failed) == 0 {
+		color.Green("Installed %d packages from %s.", len(entries), source)
+		return nil
+	}
+	color.Red("These packages could not be installed:")
+	for _, entry := range failed {
+		fmt.Printf("  - %s (%s)\n", entry.Package, entry.Manager.Name)
+	}
+	err := newError(ErrCommandFailed, "%d of %d packages from %s failed to install", len(failed), len(entries), source)
+	if !retryFailed {
+		err.Hint = "Pass --retry-failed to give failed packages a second attempt."
+	}
+	return err
+}
+
+// handleMigrate switches the project from pm to the manager named by
+// targetKey: it deletes pm's lockfiles, records the target in the project
+// config and installs from scratch with it.
+func handleMigrate(pm PackageManagerInfo, targetKey string) error {
+	target, ok := supportedManagers[targetKey]
+	if !ok {
+		return newError(ErrManagerUnsupported, "package manager '%s' is not supported", targetKey)
+	}
+	if target.Name == pm.Name {
+		return newError(ErrUsage, "this project already uses %s", pm.Name)
+	}
+	if family := managerFamily(pm); family == "" || !slices.Contains(managerAliases[family], targetKey) {
+		err := newError(ErrUsage, "can't migrate from %s to %s: they don't install from the same manifest", pm.Name, target.Name)
+		if family != "" {
+			err.Hint = fmt.Sprintf("%s projects can migrate to %s.", pm.Name, strings.Join(managerAliases[family], ", "))
+		}
+		return err
+	}
+	// Nothing is touched until the target can run, so a failed migration
+	// leaves the old lockfile and config in place.
+	if _, err := lookPathCached(target.Executable); err != nil && !miseProvides(target.Executable) && !installManager(target) {
+		return &uniError{
+			Category: ErrManagerNotInstalled,
+			Err:      fmt.Errorf("%s (%s) is not installed or not in your PATH; nothing was changed", target.Name, target.Executable),
+			Hint:     target.InstallationHint,
+		}
+	}
+	// The install below must use the commands of the installed version,
+	// as it would after detection.
+	target = resolveVariant(target)
+
+	color.Cyan("🔀 Migrating from %s to %s...", pm.Name, target.Name)
+	for _, lockFile := range pm.LockFiles {
+		if _, err := os.Stat(projectPath(lockFile)); err != nil {
+			continue
+		}
+		if dryRun {
+			color.Cyan("Would remove %s.", lockFile)
+			continue
+		}
+		if err := os.Remove(projectPath(lockFile)); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", lockFile, err)
+		}
+		color.Cyan("Removed %s.", lockFile)
+	}
+
+	configFile := projectConfigPath()
+	if dryRun {
+		color.Cyan("Would set manager = %q in %s.", targetKey, configFile)
+	} else {
+		if err := setConfigValue(configFile, "manager", strconv.Quote(targetKey)); err != nil {
+			return fmt.Errorf("failed to update %s: %w", configFile, err)
+		}
+		color.Cyan("Set manager = %q in %s.", targetKey, configFile)
+	}
+
+	if err := runCliCommand(target, []string{"install"}); err != nil {
+		return err
+	}
+	if !dryRun {
+		color.Green("Migrated to %s.", target.Name)
+	}
+	return nil
+}
+
+// outdatedReport is one manager's share of `uni outdated --all-managers`.
+type outdatedReport struct {
+	Manager string `json:"manager"`
+	File    string `json:"file"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// projectManagers returns the managers with a lock or manifest file in the
+// project. Managers sharing files, such as the node family, are narrowed to
+// the one detection would pick.
+func projectManagers() []detection {
+	var found []detection
+	seen := map[string]bool{}
+	for _, key := range orderedManagerKeys() {
+		pm := supportedManagers[key]
+		file := ""
+		for _, f := range slices.Concat(pm.LockFiles, pm.MetadataFiles) {
+			if _, err := statProjectFile(f); err == nil {
+				file = f
+				break
+			}
+		}
+		if file == "" {
+			continue
+		}
+		result := detection{Key: key, Manager: pm, File: file}
+		for alias, family := range managerAliases {
+			if slices.Contains(family, key) {
+				result = detectFamilyManager(alias, family)
+			}
+		}
+		if !seen[result.Key] {
+			seen[result.Key] = true
+			found = append(found, result)
+		}
+	}
+	return found
+}
+
+// handleOutdatedAll runs every project manager's outdated command at once
+// and prints the reports grouped by manager.
+func handleOutdatedAll(extra []string) error {
+	managers := projectManagers()
+	if len(managers) == 0 {
+		color.Yellow("No lock or manifest files found, so there is nothing to check.")
+		return nil
+	}
+	color.Cyan("🔎 Checking %d package managers for outdated dependencies...", len(managers))
+	reports, _ := fetchDetails(managers, func(result detection) (outdatedReport, error) {
+		pm := resolveVariant(result.Manager)
+		report := outdatedReport{Manager: pm.Name, File: result.File}
+		switch _, err := lookPathCached(pm.Executable); {
+		case pm.OutdatedCmd == "":
+			report.Error = fmt.Sprintf("%s can't list outdated dependencies", pm.Name)
+			return report, nil
+		case err != nil:
+			report.Error = fmt.Sprintf("%s is not installed", pm.Executable)
+			return report, nil
+		}
+		cmd, _, cancel := newCliCommand(nil, pm.Executable, append(strings.Fields(pm.OutdatedCmd), extra...)...)
+		defer cancel()
+		var out, errOut bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &errOut
+		// Several managers, npm among them, exit non-zero just because
+		// something is outdated, so a failure only counts without output.
+		if err := cmd.Run(); err != nil && out.Len() == 0 {
+			report.Error = err.Error()
+			if msg := strings.TrimSpace(errOut.String()); msg != "" {
+				report.Error = msg
+			}
+		}
+		report.Output = strings.TrimRight(out.String(), "\n")
+		return report, nil
+	})
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+	for _, report := range reports {
+		fmt.Println(color.CyanString("== %s (%s) ==", report.Manager, report.File))
+		switch {
+		case report.Error != "":
+			color.Red("Failed: %s", report.Error)
+		case report.Output == "":
+			color.Green("Everything is up to date.")
+		default:
+			fmt.Println(report.Output)
+		}
+	}
+	return nil
+}
+
+// handleRollback restores pm's lockfiles to their last committed version
+// and reinstalls from them, undoing an install that broke the project.
+func handleRollback(pm PackageManagerInfo) error {
+	git := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	git.Dir = workDir
+	if err := git.Run(); err != nil {
+		return newError(ErrUsage, "rollback needs a git repository")
+	}
+
+	restored := map[string][]byte{}
+	for _, lockFile := range pm.LockFiles {
+		// The ./ prefix resolves the path against git.Dir rather than the
+		// repository root.
+		git := exec.Command("git", "show", "HEAD:./"+lockFile)
+		git.Dir = workDir
+		content, err := git.Output()
+		if err != nil {
+			continue
+		}
+		restored[lockFile] = content
+	}
+	if len(restored) == 0 {
+		return newError(ErrUsage, "no %s lockfile (%s) is committed at HEAD", pm.Name, strings.Join(pm.LockFiles, ", "))
+	}
+
+	for _, lockFile := range slices.Sorted(maps.Keys(restored)) {
+		if dryRun {
+			color.Cyan("Would restore %s from HEAD.", lockFile)
+			continue
+		}
+		if err := os.WriteFile(projectPath(lockFile), restored[lockFile], 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", lockFile, err)
+		}
+		color.Cyan("⏪ Restored %s from HEAD.", lockFile)
+	}
+
+	install := []string{"install"}
+	if pm.FrozenCmd != "" {
+		install = append(install, "--frozen")
+	}
+	if err := runCliCommand(pm, install); err != nil {
+		return err
+	}
+	if !dryRun {
+		color.Green("Rolled back to the committed %s lockfile.", pm.Name)
+	}
+	return nil
+}
+
+// chooseInitManager resolves an ecosystem alias given to `uni init`, such
+// as node, to one of its managers. The default is the manager the project's
+// files point at, else the first installed one in the family; in a terminal
+// the user picks from the family with that preselected.
+func chooseInitManager(alias string, family []string) string {
+	choice := detectFamilyManager(alias, family).Key
+	if _, err := lookPathCached(supportedManagers[choice].Executable); err != nil && choice == family[0] {
+		if found := slices.IndexFunc(family, func(key string) bool {
+			_, err := lookPathCached(supportedManagers[key].Executable)
+			return err == nil
+		}); found >= 0 {
+			choice = family[found]
+		}
+	}
+	if !interactive() {
+		color.Cyan("Using %s for %s; run 'uni init <manager>' to pick another.", supportedManagers[choice].Name, alias)
+		return choice
+	}
+
+	color.Cyan("Which %s package manager?", alias)
+	for i, key := range family {
+		pm := supportedManagers[key]
+		line := fmt.Sprintf("  %d. %s", i+1, pm.Name)
+		if _, err := lookPathCached(pm.Executable); err != nil {
+			line += color.YellowString(" (not installed)")
+		}
+		if key == choice {
+			line += " (default)"
+		}
+		fmt.Println(line)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print(color.CyanString("Choose [1-%d, Enter for %s]: ", len(family), supportedManagers[choice].Name))
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" || err != nil {
+			return choice
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(family) {
+			return family[n-1]
+		}
+		if slices.Contains(family, answer) {
+			return answer
+		}
+		color.Yellow("Enter a number from 1 to %d, or a manager name.", len(family))
+	}
+}
+
+func handleInit(managerKey string) {
+	if family, ok := managerAliases[managerKey]; ok {
+		managerKey = chooseInitManager(managerKey, family)
+	}
+	pm, ok := supportedManagers[managerKey]
+	if !ok {
+		exitWithError(newError(ErrManagerUnsupported, "package manager '%s' is not supported for init", managerKey))
+	}
+	color.Green("Initializing new %s project...", pm.Name)
+	// --config names the file to write, as it names the one read.
+	configName := uniConfigFile
+	if configPath != "" {
+		configName = configPath
+	}
+	err := os.WriteFile(projectConfigPath(), []byte(managerKey), 0644)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to write %s file: %w", configName, err))
+	}
+	color.Green("Created '%s' to use %s in this directory.", configName, pm.Name)
+	if pm.InitArgs != nil {
 		color.Cyan("Running '%s %s'...", pm.Executable, strings.Join(pm.InitArgs, " "))
 		executeCliCommand(pm, pm.InitArgs)
 	}
+	if managerKey == "bun" {
+		// bun init writes whichever lockfile format the installed Bun uses.
+		for _, lockFile := range pm.LockFiles {
+			if _, err := os.Stat(projectPath(lockFile)); err == nil {
+				logBunLockfile(lockFile)
+				break
+			}
+		}
+	}
+}
+
+// handleInitCheck implements `uni init --check`. It reports whether the
+// project is already set up for managerKey, changing nothing, and exits
+// non-zero when it isn't.
+func handleInitCheck(managerKey string) {
+	if family, ok := managerAliases[managerKey]; ok {
+		// Any manager of the family counts; check the one .unirc selects.
+		managerKey = detectFamilyManager(managerKey, family).Key
+		if config, err := loadConfig(projectConfigPath()); err == nil && slices.Contains(family, config.Manager) {
+			managerKey = config.Manager
+		}
+	}
+	pm, ok := supportedManagers[managerKey]
+	if !ok {
+		exitWithError(newError(ErrManagerUnsupported, "package manager '%s' is not supported for init", managerKey))
+	}
+	initialized := true
+
+	path := projectConfigPath()
+	config, err := loadConfig(path)
+	switch {
+	case err != nil:
+		exitWithError(&uniError{Category: ErrConfig, Err: err})
+	case config.Manager == managerKey:
+		color.Green("✅ %s selects %s", path, pm.Name)
+	case config.Manager == "":
+		color.Red("❌ %s does not select a manager", path)
+		initialized = false
+	default:
+		color.Red("❌ %s selects '%s', not '%s'", path, config.Manager, managerKey)
+		initialized = false
+	}
+
+	if projectFiles := slices.Concat(pm.LockFiles, pm.MetadataFiles); len(projectFiles) > 0 {
+		found := slices.IndexFunc(projectFiles, func(file string) bool {
+			_, err := os.Stat(projectPath(file))
+			return err == nil
+		})
+		if found >= 0 {
+			color.Green("✅ Found %s", projectFiles[found])
+		} else {
+			color.Red("❌ None of %s exist", strings.Join(projectFiles, ", "))
+			initialized = false
+		}
+	}
+
+	if !initialized {
+		exitWithError(&uniError{Category: ErrCommandFailed, Err: fmt.Errorf("project is not initialized for %s", pm.Name), Hint: fmt.Sprintf("Run 'uni init %s' to set it up.", managerKey)})
+	}
+	color.Green("Project is initialized for %s.", pm.Name)
 }
 
 func printHelp() {
 	fmt.Println(color.CyanString("uni - The Universal Package Manager Wrapper"))
 	fmt.Println("\n" + color.YellowString("Usage:"))
 	fmt.Println("  uni <command> [args...]")
-	fmt.Println("  uni init <manager>")
+	fmt.Println("  uni init [--check] <manager>")
+	fmt.Println("                         --check reports whether the project is already set up, changing nothing")
+	fmt.Println("                         <manager> may be node, python or ruby to choose one from that family")
 	fmt.Println("  uni --pkg=<manager> <command> [args...]")
+	fmt.Println("                         <manager> may also be node, python or ruby, or be set with UNI_PKG")
+	fmt.Println("  uni --cwd=<path> <command> [args...]")
+	fmt.Println("  uni --manifest=<file> <command> [args...]")
+	fmt.Println("                         Run in the file's directory and install from it where the manager allows")
+	fmt.Println("  uni --venv=<path> <command> [args...]")
+	fmt.Println("                         Run pip or uv in that virtual environment; defaults to VIRTUAL_ENV")
+	fmt.Println("  uni --cmd-timeout=<duration> <command> [args...]")
+	fmt.Println("  uni --dry-run <command> [args...]")
+	fmt.Println("  uni --detect-from=<lockfile> <command> [args...]")
+	fmt.Println("  uni --config=<file> <command> [args...]")
+	fmt.Println("                         Read <file> instead of .unirc; defaults come from the user config dir's uni/config")
+	fmt.Println("  uni --env KEY=VAL <command> [args...]")
+	fmt.Println("                         Set an environment variable for the manager; repeatable")
+	fmt.Println("  uni --jobs=<n> <command> [args...]")
+	fmt.Println("                         Run at most n searches or processes in parallel (default: CPU count)")
+	fmt.Println("  uni --auto-install-manager <command> [args...]")
+	fmt.Println("                         Install a missing manager with its 'Run:' hint without asking")
+	fmt.Println("  uni --verbose <command> [args...]")
+	fmt.Println("  uni --strict <command> [args...]")
+	fmt.Println("                         Fail if the configured manager isn't installed instead of detecting another")
+	fmt.Println("  uni --check-engines <command> [args...]")
+	fmt.Println("                         Warn before node installs when node doesn't match .nvmrc or engines.node")
+	fmt.Println("  uni --json <command> [args...]")
+	fmt.Println("  uni --color=<always|never|auto> <command> [args...]")
+	fmt.Println("                         auto (the default) colors output only on a terminal")
+	fmt.Println("  uni --no-color <command> [args...]")
+	fmt.Println("  uni --offline <command> [args...]")
+	fmt.Println("                         Never search over the network; UNI_OFFLINE=1 does the same")
 	fmt.Println("\n" + color.YellowString("Commands:"))
-	fmt.Println("  install, add, i        Install packages")
+	fmt.Println("  install, add, i        Install packages (--frozen installs strictly from the lockfile)")
+	fmt.Println("                         Pass '-' to read package names from stdin, --preview to summarize changes")
+	fmt.Println("                         --manifest-only updates the manifest and lockfile without downloading")
+	fmt.Println("                         --ignore-scripts skips package install scripts where the manager allows it")
+	fmt.Println("                         --prefer-offline installs from the manager's cache before the network")
+	fmt.Println("                         --no-lockfile installs without writing a lockfile (npm, pnpm, Yarn 1)")
+	fmt.Println("                         --exact, -E saves exact versions instead of ranges (npm, pnpm, Yarn, Bun)")
+	fmt.Println("                         --include-prerelease allows pre-releases (pip --pre, npm pkg@next)")
+	fmt.Println("                         --cask or --formula picks the Homebrew package type when both exist")
+	fmt.Println("                         --summary reports how many packages changed and how long it took")
+	fmt.Println("                         --retry-failed with '-' installs one package at a time, retrying failures")
 	fmt.Println("  uninstall, rm, un      Remove packages")
+	fmt.Println("                         --packages-from=<file> removes every package listed in a file (--yes skips the prompt)")
+	fmt.Println("  freeze, export         Snapshot installed dependencies (--output=<file> writes to a file)")
+	fmt.Println("  reinstall              Reinstall packages (--force installs even if removal fails)")
+	fmt.Println("  list, ls               List installed dependencies (--tree shows the tree, --depth=N limits it)")
+	fmt.Println("  outdated               List dependencies with newer versions available")
+	fmt.Println("                         --all-managers checks every manager with files in the project")
+	fmt.Println("  which <package>        Show where an installed package lives and its version")
+	fmt.Println("  dedupe, ddp            Collapse duplicated dependencies")
+	fmt.Println("  prune                  Remove packages that aren't in the manifest")
 	fmt.Println("  search, s              Search for packages using official APIs or local commands")
+	fmt.Println("                         Results are numbered; in a terminal you can pick one to install")
+	fmt.Println("                         With --pkg=all, searches every supported registry in parallel")
+	fmt.Println("                         --open[=N] opens the homepage of the first (or Nth) result")
+	fmt.Println("                         --since=<30d|2w|12h> hides npm packages not published recently")
+	fmt.Println("                         --tap=<user/repo> only shows Homebrew results from that tap")
+	fmt.Println("                         --cask or --formula only shows Homebrew results of that type")
+	fmt.Println("                         --exact only shows a package named exactly like the query")
+	fmt.Println("                         --registry=<url> searches a different npm registry this once")
+	fmt.Println("                         --min-score=<0..1> hides npm results with a lower search score")
+	fmt.Println("                         --max-desc=N cuts descriptions to N characters (default 120, 0 for all)")
+	fmt.Println("                         --output=<file> writes the results (JSON with --json) to a file")
+	fmt.Println("                         --latest-only looks up each result's latest version (npm, CocoaPods, Hackage)")
+	fmt.Println("                         --include-prerelease shows pre-release versions, e.g. npm's next tag")
+	fmt.Println("                         --json-lines prints one JSON object per result as results arrive")
+	fmt.Println("                         --dedupe-results merges --pkg=all results sharing a name, listing")
+	fmt.Println("                         every manager that provides it")
+	fmt.Println("  migrate <manager>      Switch the project to another manager and reinstall with it")
+	fmt.Println("  rollback               Restore the lockfile from git HEAD and reinstall from it")
+	fmt.Println("  bundle <file>          Install every package listed in a manifest file, one at a time")
+	fmt.Println("                         --retry-failed retries the packages that failed once the rest are done")
+	fmt.Println("  config validate        Check .unirc for unknown keys, wrong types and unsupported managers")
+	fmt.Println("  config get <key>       Print a setting, taking the global config into account")
+	fmt.Println("  config set <key> <val> Set a setting in .unirc, keeping the rest of the file")
+	fmt.Println("  detect [--json]        Show which package manager would be used and why")
+	fmt.Println("  help [command]         Show this overview, or details for one command")
+	fmt.Println("  doctor                 Check which package managers are installed and working")
+	fmt.Println("  cache verify           Remove corrupt cache files and leftovers from interrupted writes")
+	fmt.Println("  stats [--json]         Show cache use, the most searched queries and detected managers")
+	fmt.Println("                         Counters stay in the cache dir; set UNI_NO_STATS=1 to stop recording")
+	fmt.Println("  self-update            Update uni to the latest release (--check-only just reports it)")
+	fmt.Println("  managers [--json]      List supported package managers and whether they're installed")
 	fmt.Println("  init                   Initialize a new project with a specific manager")
-	fmt.Println("  run, ...               Any other command is passed through (e.g., 'uni run dev')")
+	fmt.Println("  x, exec <command>      Run a package without installing it (--no-cache re-resolves the runner)")
+	fmt.Println("                         --version=<ver> runs that version of the package, e.g. create-vite@5")
+	fmt.Println("  run <script> -- <args> Run a package script, forwarding the arguments after --")
+	fmt.Println("  ...                    Any other command is passed through (e.g., 'uni outdated')")
+	fmt.Println("\n" + color.YellowString("Project files:"))
+	fmt.Println("  Every command treats project files that take over 2s to check, e.g. on a stalled")
+	fmt.Println("  network mount, as missing.")
+	fmt.Println("  With a mise config (mise.toml, .mise.toml, .config/mise.toml or .rtx.toml) and mise")
+	fmt.Println("  installed, manager commands run through 'mise exec --' at the versions it pins; set")
+	fmt.Println("  UNI_NO_MISE=1 to use the managers on PATH instead.")
+	fmt.Println("\n" + color.YellowString("Exit codes:"))
+	fmt.Println("  0  success                       4  package manager not installed")
+	fmt.Println("  1  command failed                5  network error")
+	fmt.Println("  2  invalid usage                 6  invalid configuration")
+	fmt.Println("  3  package manager not supported")
+	fmt.Println("  With --json, errors are printed as {\"error\":{\"code\":...,\"message\":...}}.")
 	fmt.Println("\n" + color.YellowString("Examples:"))
 	fmt.Println(color.GreenString("  uni install fastify      ") + "# Automatically uses npm/pnpm/yarn/bun")
 	fmt.Println(color.GreenString("  uni search react         ") + "# Search for 'react' using the detected manager's API")
 	fmt.Println(color.GreenString("  uni --pkg=brew s git     ") + "# Search for 'git' using Homebrew's local command")
+	fmt.Println("\nRun 'uni help <command>' for a command's flags and what it runs for each manager.")
 }
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// A single job slot makes nested slot use deadlock instead of racing.
+	jobs = 1
+	os.Setenv("UNI_NO_STATS", "1")
+	os.Exit(m.Run())
+}
+
+// fakeNPMRegistry serves a search listing a and b, and dist-tags for both.
+func fakeNPMRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/-/v1/search":
+			w.Write([]byte(`{"objects":[{"package":{"name":"a","version":"1.0.0"}},{"package":{"name":"b","version":"2.0.0"}}],"total":2}`))
+		case "/a":
+			w.Write([]byte(`{"dist-tags":{"latest":"1.4.0"}}`))
+		case "/b":
+			w.Write([]byte(`{"dist-tags":{"latest":"2.1.0"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSearchAllLatestOnlyWithOneJob(t *testing.T) {
+	srv := fakeNPMRegistry(t)
+	output := filepath.Join(t.TempDir(), "results.jsonl")
+	opts := searchOptions{LatestOnly: true, Registry: srv.URL, Output: output, JSONLines: true}
+	managers := []PackageManagerInfo{supportedManagers["npm"], supportedManagers["npm"]}
+
+	done := make(chan error, 1)
+	go func() { done <- streamSearchAll(managers, "foo", opts) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("streamSearchAll: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("search with --latest-only and --jobs=1 did not finish; job slots deadlocked")
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d results, want 4:\n%s", len(lines), data)
+	}
+	want := map[string]string{"a": "1.4.0", "b": "2.1.0"}
+	for _, line := range lines {
+		var result PackageResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("decoding %q: %v", line, err)
+		}
+		if result.Version != want[result.Name] {
+			t.Errorf("%s: version %q, want %q", result.Name, result.Version, want[result.Name])
+		}
+	}
+}
+
+func TestInstallSpecArgsPythonPins(t *testing.T) {
+	tests := []struct {
+		name    string
+		manager string
+		spec    string
+		want    []string
+	}{
+		{"pin", "pip", "requests@1.2", []string{"install", "requests==1.2"}},
+		{"pin with extras", "pip", "requests[socks]@2.31.0", []string{"install", "requests[socks]==2.31.0"}},
+		{"uv pin", "uv", "httpx@0.27.0", []string{"add", "httpx==0.27.0"}},
+		{"pipx pin", "pipx", "black@24.1.0", []string{"install", "black==24.1.0"}},
+		{"unversioned", "pip", "requests", []string{"install", "requests"}},
+		{"specifier", "pip", "django>=4,<5", []string{"install", "django>=4,<5"}},
+		{"environment marker", "pip", `requests>=2; python_version < "3.8"`, []string{"install", `requests>=2; python_version < "3.8"`}},
+		{"direct URL", "pip", "pkg @ https://example.com/pkg-1.0-py3-none-any.whl", []string{"install", "pkg @ https://example.com/pkg-1.0-py3-none-any.whl"}},
+		{"direct URL without spaces", "pip", "pkg@https://example.com/pkg.tar.gz", []string{"install", "pkg@https://example.com/pkg.tar.gz"}},
+		{"poetry keeps @", "poetry", "requests@2.31.0", []string{"add", "requests@2.31.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := installSpecArgs(supportedManagers[tt.manager], []string{tt.spec})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("installSpecArgs(%s, %q) = %q, want %q", tt.manager, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewestVersionRanksPrereleasesBelowTheirRelease(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     string
+	}{
+		{[]string{"1.2.0-rc.1", "1.2.0"}, "1.2.0"},
+		{[]string{"1.2.0", "1.2.0-rc.1"}, "1.2.0"},
+		{[]string{"1.1.0", "1.2.0-rc.1"}, "1.2.0-rc.1"},
+		{[]string{"1.2.0-rc.2", "1.2.0-rc.10", "1.2.0-beta.3"}, "1.2.0-rc.10"},
+		{[]string{"1.2.0-alpha", "1.2.0-alpha.1"}, "1.2.0-alpha.1"},
+	}
+	for _, tt := range tests {
+		if got := newestVersion(tt.versions, true); got != tt.want {
+			t.Errorf("newestVersion(%q) = %q, want %q", tt.versions, got, tt.want)
+		}
+	}
+}
+
+func TestNodePrereleaseArgsOnlyUsesANewerNextTag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/has-next":
+			w.Write([]byte(`{"dist-tags":{"latest":"1.0.0","next":"2.0.0-rc.1"}}`))
+		case "/stale-next":
+			w.Write([]byte(`{"dist-tags":{"latest":"2.0.0","next":"2.0.0-rc.1"}}`))
+		case "/no-next":
+			w.Write([]byte(`{"dist-tags":{"latest":"1.0.0"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("UNI_NPM_REGISTRY", srv.URL)
+
+	args := []string{"install", "has-next", "stale-next", "no-next", "missing", "pinned@1.0.0", "--save-dev"}
+	want := []string{"install", "has-next@next", "stale-next", "no-next", "missing", "pinned@1.0.0", "--save-dev"}
+	if got := nodePrereleaseArgs(args); !slices.Equal(got, want) {
+		t.Errorf("nodePrereleaseArgs(%q) = %q, want %q", args, got, want)
+	}
+}
+
+func TestApplyVenvReturnsEnvWithoutChangingGlobals(t *testing.T) {
+	venv := t.TempDir()
+	bin := venvBinDir(venv)
+	os.MkdirAll(bin, 0755)
+	os.WriteFile(filepath.Join(venv, "pyvenv.cfg"), nil, 0644)
+	os.WriteFile(filepath.Join(bin, "pip"), nil, 0755)
+	t.Setenv("VIRTUAL_ENV", venv)
+	before := slices.Clone(extraEnv)
+
+	for range 2 {
+		for _, key := range []string{"pip", "uv"} {
+			pm, env, err := applyVenv(supportedManagers[key], []string{"install", "requests"})
+			if err != nil {
+				t.Fatalf("applyVenv(%s): %v", key, err)
+			}
+			if !slices.Contains(env, "VIRTUAL_ENV="+venv) {
+				t.Errorf("applyVenv(%s) env = %q, missing VIRTUAL_ENV", key, env)
+			}
+			if key == "pip" && pm.Executable != filepath.Join(bin, "pip") {
+				t.Errorf("pip executable = %q, want the venv's pip", pm.Executable)
+			}
+		}
+	}
+	if !slices.Equal(extraEnv, before) {
+		t.Errorf("extraEnv changed from %q to %q", before, extraEnv)
+	}
+	if _, env, _ := applyVenv(supportedManagers["npm"], []string{"install"}); env != nil {
+		t.Errorf("applyVenv(npm) env = %q, want none", env)
+	}
+}
+
+func TestApplyManifestReturnsBundlerEnv(t *testing.T) {
+	manifestFile = "Gemfile.ci"
+	t.Cleanup(func() { manifestFile = "" })
+	before := slices.Clone(extraEnv)
+
+	args, env := applyManifest(supportedManagers["bundle"], []string{"install"})
+	if !slices.Equal(args, []string{"install"}) {
+		t.Errorf("args = %q, want install unchanged", args)
+	}
+	if want := []string{"BUNDLE_GEMFILE=" + projectPath("Gemfile.ci")}; !slices.Equal(env, want) {
+		t.Errorf("env = %q, want %q", env, want)
+	}
+	if !slices.Equal(extraEnv, before) {
+		t.Errorf("extraEnv changed from %q to %q", before, extraEnv)
+	}
+}
+
+func TestToolVersionsManager(t *testing.T) {
+	oldWorkDir := workDir
+	t.Cleanup(func() { workDir = oldWorkDir })
+	node := managerAliases["node"]
+	tests := []struct {
+		name, file, want string
+	}{
+		{"nodejs plugin", "nodejs 20.11.0\n", "npm"},
+		{"node plugin", "node 20.11.0\n", "npm"},
+		{"pinned manager wins", "nodejs 20.11.0\npnpm 8.15.0\n", "pnpm"},
+		{"unrelated tools", "python 3.12.1\nruby 3.3.0\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir = t.TempDir()
+			os.WriteFile(filepath.Join(workDir, toolVersionsFile), []byte(tt.file), 0644)
+			if got := toolVersionsManager(node); got != tt.want {
+				t.Errorf("toolVersionsManager(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinnerPausingWriterWritesWholeLines(t *testing.T) {
+	var out strings.Builder
+	p := &spinnerPausingWriter{w: &out}
+	p.Write([]byte("\x1b[33m"))
+	p.Write([]byte("Warning: half"))
+	if out.Len() != 0 {
+		t.Fatalf("wrote %q before the line was complete", out.String())
+	}
+	p.Write([]byte(" a line\n\x1b[0m"))
+	if got := out.String(); got != "\x1b[33mWarning: half a line\n" {
+		t.Errorf("wrote %q, want the whole line", got)
+	}
+	p.flush()
+	if got := out.String(); !strings.HasSuffix(got, "\x1b[0m") {
+		t.Errorf("flush left %q, want the trailing reset written", got)
+	}
+}
+
+func TestProjectFileChecksTimeOutOnAStalledMount(t *testing.T) {
+	oldTimeout, oldStat, oldRead, oldWorkDir := statTimeout, statFile, readFile, workDir
+	stalled := make(chan struct{})
+	t.Cleanup(func() {
+		close(stalled)
+		statTimeout, statFile, readFile, workDir = oldTimeout, oldStat, oldRead, oldWorkDir
+		statCache = map[string]statResult{}
+	})
+	statTimeout = 50 * time.Millisecond
+	workDir = t.TempDir()
+	statCache = map[string]statResult{}
+	var calls atomic.Int32
+	statFile = func(string) (os.FileInfo, error) { calls.Add(1); <-stalled; return nil, nil }
+	readFile = func(string) ([]byte, error) { calls.Add(1); <-stalled; return nil, nil }
+
+	start := time.Now()
+	if _, err := statProjectFile("package-lock.json"); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("statProjectFile error = %v, want a deadline error", err)
+	}
+	if _, err := readProjectFile("pyproject.toml"); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("readProjectFile error = %v, want a deadline error", err)
+	}
+	if _, ok := detectCorepack(); ok {
+		t.Error("detectCorepack found a packageManager through a stalled read")
+	}
+	if key, _ := detectPyproject(); key != "" {
+		t.Errorf("detectPyproject = %q, want none", key)
+	}
+	if key := toolVersionsManager(managerAliases["node"]); key != "" {
+		t.Errorf("toolVersionsManager = %q, want none", key)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("checks took %s; they should give up after %s each", elapsed, statTimeout)
+	}
+
+	before := calls.Load()
+	statProjectFile("package-lock.json")
+	readProjectFile("pyproject.toml")
+	if calls.Load() != before {
+		t.Error("timed-out checks were retried instead of served from the cache")
+	}
+}
+
+func TestLookPathCached(t *testing.T) {
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "uni-test-tool")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Cleanup(func() {
+		forgetLookPath("uni-test-tool")
+		forgetLookPath("uni-test-missing")
+	})
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"hit", "uni-test-tool"},
+		{"miss", "uni-test-missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantPath, wantErr := exec.LookPath(tt.file)
+			path, err := lookPathCached(tt.file)
+			if path != wantPath {
+				t.Errorf("lookPathCached(%q) path = %q, want %q", tt.file, path, wantPath)
+			}
+			if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("lookPathCached(%q) error = %v, want %v", tt.file, err, wantErr)
+			}
+			if wantErr != nil && !errors.Is(err, exec.ErrNotFound) {
+				t.Errorf("lookPathCached(%q) error = %v, want exec.ErrNotFound", tt.file, err)
+			}
+		})
+	}
+
+	// With the tool gone, only the cache can still find it.
+	if err := os.Remove(tool); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := lookPathCached("uni-test-tool"); err != nil || path != tool {
+		t.Errorf("second lookPathCached = %q, %v; want the cached %q", path, err, tool)
+	}
+}
+
+func TestFetchDetails(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	items := []int{1, 2, 3, 4, 5, 6}
+	results, errs := fetchDetails(items, func(n int) (string, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if n == 4 {
+			return "", errors.New("lookup failed")
+		}
+		return strconv.Itoa(n * 10), nil
+	})
+
+	wantResults := []string{"10", "20", "30", "", "50", "60"}
+	if !slices.Equal(results, wantResults) {
+		t.Errorf("results = %q, want %q", results, wantResults)
+	}
+	for i, err := range errs {
+		if (err != nil) != (i == 3) {
+			t.Errorf("errs[%d] = %v; only index 3 should fail", i, err)
+		}
+	}
+	if got := maxInFlight.Load(); got < 1 || int(got) > jobs {
+		t.Errorf("%d fetches ran at once, want at most %d (--jobs)", got, jobs)
+	}
+}
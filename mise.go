@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// miseConfigFiles are the project files mise, formerly rtx, reads tool
+// versions from. Only the working directory is checked, not its parents.
+var miseConfigFiles = []string{".mise.toml", "mise.toml", ".config/mise.toml", ".rtx.toml"}
+
+var (
+	miseOnce sync.Once
+	miseExe  string // mise or rtx when commands should run through it, else ""
+)
+
+// miseExecutable returns the mise binary to run manager commands through:
+// set when the project has a mise config and mise (or rtx) is installed,
+// unless UNI_NO_MISE is set. The answer is worked out once per run.
+func miseExecutable() string {
+	miseOnce.Do(func() {
+		if os.Getenv("UNI_NO_MISE") != "" {
+			return
+		}
+		config := ""
+		for _, file := range miseConfigFiles {
+			if _, err := statProjectFile(file); err == nil {
+				config = file
+				break
+			}
+		}
+		if config == "" {
+			return
+		}
+		for _, exe := range []string{"mise", "rtx"} {
+			if _, err := lookPathCached(exe); err == nil {
+				miseExe = exe
+				logVerbose("found %s; running manager commands through '%s exec' for its pinned versions", config, exe)
+				return
+			}
+		}
+		color.Yellow("Warning: found %s, but mise isn't installed; using the managers on PATH instead of the pinned versions.", config)
+	})
+	return miseExe
+}
+
+// miseCommand wraps name and args in `mise exec --`, so the manager runs at
+// the version the project's mise config pins instead of whatever is first
+// on PATH. Commands are returned unchanged when mise isn't in use.
+func miseCommand(name string, args []string) (string, []string) {
+	mise := miseExecutable()
+	if mise == "" || name == mise {
+		return name, args
+	}
+	return mise, append([]string{"exec", "--", name}, args...)
+}
+
+// commandLine is name and args as they will run, through mise when
+// miseCommand wraps them, for echoing before a command runs.
+func commandLine(name string, args []string) string {
+	name, args = miseCommand(name, args)
+	return strings.Join(append([]string{name}, args...), " ")
+}
+
+// miseWhich returns the path of the executable mise supplies for this
+// project, which may not be on PATH, as when mise isn't activated in the
+// shell.
+func miseWhich(executable string) (string, bool) {
+	mise := miseExecutable()
+	if mise == "" {
+		return "", false
+	}
+	cmd := exec.Command(mise, "which", executable)
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
+
+// miseProvides reports whether mise can supply executable for this project.
+func miseProvides(executable string) bool {
+	_, ok := miseWhich(executable)
+	return ok
+}
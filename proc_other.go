@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// startInProcessGroup is a no-op on platforms without Unix process groups.
+func startInProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup falls back to killing the direct child process.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
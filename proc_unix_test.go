@@ -0,0 +1,33 @@
+//go:build unix
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCliCommandProcessGroup(t *testing.T) {
+	oldTimeout, oldTerminal := cmdTimeout, stdinIsTerminal
+	t.Cleanup(func() { cmdTimeout, stdinIsTerminal = oldTimeout, oldTerminal })
+	cmdTimeout = time.Minute
+	tests := []struct {
+		name      string
+		terminal  bool
+		wantGroup bool
+	}{
+		{"stdin is a terminal", true, false},
+		{"stdin is not a terminal", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdinIsTerminal = func() bool { return tt.terminal }
+			cmd, _, cancel := newCliCommand(nil, "true")
+			defer cancel()
+			group := cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid
+			if group != tt.wantGroup {
+				t.Errorf("Setpgid = %v, want %v", group, tt.wantGroup)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// version is the release this binary was built from, set at build time with
+// -ldflags "-X main.version=v1.2.3". Builds from source report "dev".
+var version = "dev"
+
+const latestReleaseURL = "https://api.github.com/repos/michaelessiet/uni/releases/latest"
+
+// githubRelease holds the parts of a GitHub release that self-update uses.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+		Size int64  `json:"size"`
+	} `json:"assets"`
+}
+
+// parseVersion splits a tag like v1.2.3 into its numeric parts, ignoring any
+// pre-release or build suffix.
+func parseVersion(tag string) ([]int, bool) {
+	tag = strings.TrimPrefix(tag, "v")
+	tag, _, _ = strings.Cut(tag, "-")
+	tag, _, _ = strings.Cut(tag, "+")
+	var parts []int
+	for _, field := range strings.Split(tag, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, len(parts) > 0
+}
+
+// prereleaseSuffix returns the pre-release part of a version, e.g. rc.1 for
+// v1.2.0-rc.1+build.5, or "" for a release.
+func prereleaseSuffix(tag string) string {
+	tag, _, _ = strings.Cut(tag, "+")
+	_, pre, _ := strings.Cut(tag, "-")
+	return pre
+}
+
+// newerPrerelease reports whether pre-release a ranks above b under semver:
+// a release above any pre-release, then dot-separated identifiers compared
+// numerically where both are numbers and lexically otherwise.
+func newerPrerelease(a, b string) bool {
+	switch {
+	case a == b:
+		return false
+	case a == "":
+		return true
+	case b == "":
+		return false
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < min(len(as), len(bs)); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			return an > bn
+		case aErr == nil || bErr == nil:
+			// Numeric identifiers rank below alphanumeric ones.
+			return bErr == nil
+		}
+		return as[i] > bs[i]
+	}
+	return len(as) > len(bs)
+}
+
+// newerVersion reports whether tag is a later release than current. A
+// pre-release ranks below the release it precedes, so 1.2.0 is newer than
+// 1.2.0-rc.1.
+func newerVersion(tag, current string) bool {
+	latest, ok := parseVersion(tag)
+	installed, ok2 := parseVersion(current)
+	if !ok || !ok2 {
+		return false
+	}
+	for i := 0; i < max(len(latest), len(installed)); i++ {
+		var a, b int
+		if i < len(latest) {
+			a = latest[i]
+		}
+		if i < len(installed) {
+			b = installed[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return newerPrerelease(prereleaseSuffix(tag), prereleaseSuffix(current))
+}
+
+func fetchLatestRelease() (githubRelease, error) {
+	req, err := http.NewRequest(http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return githubRelease{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return githubRelease{}, &uniError{Category: ErrNetwork, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := newError(ErrNetwork, "GitHub returned %s for the latest release", resp.Status)
+		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+			err.Hint = "GitHub limits anonymous API requests; set GITHUB_TOKEN to raise the limit."
+		}
+		return githubRelease{}, err
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return githubRelease{}, fmt.Errorf("could not parse the GitHub release: %w", err)
+	}
+	return release, nil
+}
+
+// archNames lists the spellings release assets use for each GOARCH.
+var archNames = map[string][]string{
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"arm64", "aarch64"},
+	"386":   {"386", "i386"},
+}
+
+// releaseAsset picks the release asset built for this OS and architecture.
+func releaseAsset(release githubRelease) (name, url string, size int64, ok bool) {
+	arches := archNames[runtime.GOARCH]
+	if arches == nil {
+		arches = []string{runtime.GOARCH}
+	}
+	for _, asset := range release.Assets {
+		lower := strings.ToLower(asset.Name)
+		if !strings.Contains(lower, runtime.GOOS) || strings.Contains(lower, "checksum") || strings.HasSuffix(lower, ".sha256") {
+			continue
+		}
+		for _, arch := range arches {
+			if strings.Contains(lower, arch) {
+				return asset.Name, asset.URL, asset.Size, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// releaseChecksum returns the published SHA-256 of asset, or "" when the
+// release has no checksums file.
+func releaseChecksum(client *http.Client, release githubRelease, asset string) (string, error) {
+	for _, a := range release.Assets {
+		if !strings.Contains(strings.ToLower(a.Name), "checksum") {
+			continue
+		}
+		resp, err := client.Get(a.URL)
+		if err != nil {
+			return "", &uniError{Category: ErrNetwork, Err: err}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", newError(ErrNetwork, "downloading %s: %s", a.Name, resp.Status)
+		}
+		// Lines are `<sha256>  <file name>`, as written by sha256sum.
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+				return fields[0], nil
+			}
+		}
+		return "", fmt.Errorf("%s has no entry for %s", a.Name, asset)
+	}
+	return "", nil
+}
+
+// extractBinary returns the uni executable from a downloaded asset, which is
+// either the bare binary or a .tar.gz or .zip archive containing it.
+func extractBinary(name string, data []byte) ([]byte, error) {
+	binary := "uni"
+	if runtime.GOOS == "windows" {
+		binary = "uni.exe"
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		archive := tar.NewReader(gz)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if filepath.Base(header.Name) == binary && header.Typeflag == tar.TypeReg {
+				return io.ReadAll(archive)
+			}
+		}
+	case strings.HasSuffix(name, ".zip"):
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range archive.File {
+			if filepath.Base(file.Name) == binary {
+				r, err := file.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer r.Close()
+				return io.ReadAll(r)
+			}
+		}
+	default:
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s does not contain %s", name, binary)
+}
+
+// replaceExecutable swaps the running executable for binary. The new file is
+// written next to the old one and renamed over it, so an interrupted update
+// never leaves a partial binary behind.
+func replaceExecutable(binary []byte) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".uni-update-*")
+	if err != nil {
+		return exe, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return exe, err
+	}
+	if err := tmp.Close(); err != nil {
+		return exe, err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return exe, err
+	}
+	return exe, os.Rename(tmp.Name(), exe)
+}
+
+// handleSelfUpdate implements `uni self-update [--check-only]`.
+func handleSelfUpdate(checkOnly bool) {
+	if version == "dev" {
+		exitWithError(&uniError{
+			Category: ErrUsage,
+			Err:      errors.New("this is a development build, so there is no release to compare against"),
+			Hint:     "Install a release from https://github.com/michaelessiet/uni/releases to use self-update.",
+		})
+	}
+	release, err := fetchLatestRelease()
+	if err != nil {
+		exitWithError(err)
+	}
+	if !newerVersion(release.TagName, version) {
+		color.Green("✅ uni %s is up to date.", version)
+		return
+	}
+	color.Cyan("uni %s is available (installed: %s).", release.TagName, version)
+	if checkOnly {
+		if release.HTMLURL != "" {
+			color.Cyan("Release notes: %s", release.HTMLURL)
+		}
+		return
+	}
+
+	name, assetURL, size, ok := releaseAsset(release)
+	if !ok {
+		exitWithError(newError(ErrManagerUnsupported, "release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH))
+	}
+	download := &http.Client{Transport: httpClient.Transport, Timeout: 5 * time.Minute}
+	color.Cyan("Downloading %s...", name)
+	resp, err := download.Get(assetURL)
+	if err != nil {
+		exitWithError(&uniError{Category: ErrNetwork, Err: err})
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		exitWithError(&uniError{Category: ErrNetwork, Err: fmt.Errorf("downloading %s: %w", name, err)})
+	}
+	if resp.StatusCode != http.StatusOK {
+		exitWithError(newError(ErrNetwork, "downloading %s: %s", name, resp.Status))
+	}
+	if size > 0 && int64(len(data)) != size {
+		exitWithError(newError(ErrNetwork, "downloaded %d bytes of %s, expected %d", len(data), name, size))
+	}
+	checksum, err := releaseChecksum(download, release, name)
+	if err != nil {
+		exitWithError(err)
+	}
+	if checksum != "" {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != strings.ToLower(checksum) {
+			exitWithError(newError(ErrCommandFailed, "checksum mismatch for %s; not updating", name))
+		}
+		logVerbose("%s: checksum verified", name)
+	}
+	binary, err := extractBinary(name, data)
+	if err != nil {
+		exitWithError(fmt.Errorf("unpacking %s: %w", name, err))
+	}
+
+	exe, err := replaceExecutable(binary)
+	if err != nil {
+		uniErr := &uniError{Category: ErrCommandFailed, Err: fmt.Errorf("could not replace %s: %w", exe, err)}
+		if errors.Is(err, os.ErrPermission) {
+			uniErr.Hint = fmt.Sprintf("%s isn't writable by you; rerun with sudo or reinstall uni somewhere you own.", filepath.Dir(exe))
+		}
+		exitWithError(uniErr)
+	}
+	color.Green("✅ Updated uni to %s.", release.TagName)
+}
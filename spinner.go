@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinner shows activity on stderr while a slow search runs.
+type spinner struct {
+	message   string
+	done      chan struct{}
+	wg        sync.WaitGroup
+	output    io.Writer // color.Output and color.Error before the spinner started
+	errOutput io.Writer
+	pausing   []*spinnerPausingWriter
+}
+
+var (
+	// spinnerMu orders drawing the spinner against other terminal output.
+	spinnerMu sync.Mutex
+	// spinnerShown is set while a spinner frame is on screen.
+	spinnerShown bool
+)
+
+// clearSpinnerLine erases the spinner frame, if one is shown, so the next
+// write starts on a clean line. spinnerMu must be held.
+func clearSpinnerLine() {
+	if spinnerShown {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		spinnerShown = false
+	}
+}
+
+// spinnerPausingWriter holds writes until a full line is ready, then clears
+// the spinner and writes the line, so messages such as warnings from
+// parallel searches are never drawn over or split by a frame. The spinner
+// redraws below them on its next frame.
+type spinnerPausingWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+func (p *spinnerPausingWriter) Write(b []byte) (int, error) {
+	spinnerMu.Lock()
+	defer spinnerMu.Unlock()
+	p.pending = append(p.pending, b...)
+	end := bytes.LastIndexByte(p.pending, '\n')
+	if end < 0 {
+		return len(b), nil
+	}
+	clearSpinnerLine()
+	_, err := p.w.Write(p.pending[:end+1])
+	p.pending = slices.Clone(p.pending[end+1:])
+	return len(b), err
+}
+
+// flush writes anything still held, such as a trailing color reset.
+func (p *spinnerPausingWriter) flush() {
+	spinnerMu.Lock()
+	defer spinnerMu.Unlock()
+	if len(p.pending) > 0 {
+		p.w.Write(p.pending)
+		p.pending = nil
+	}
+}
+
+// spinnerEnabled reports whether a spinner can be drawn without corrupting
+// output: colors must be on, --json and --verbose off, since verbose logging
+// writes to stderr throughout, and stderr a terminal.
+func spinnerEnabled() bool {
+	if color.NoColor || jsonOutput || verbose {
+		return false
+	}
+	fd := os.Stderr.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// startSpinner starts a spinner showing message. It returns nil, which is
+// safe to stop, when spinners are disabled.
+func startSpinner(message string) *spinner {
+	if !spinnerEnabled() {
+		return nil
+	}
+	s := &spinner{message: message, done: make(chan struct{}), output: color.Output, errOutput: color.Error}
+	// Messages printed while the spinner runs go through color's writers.
+	s.pausing = []*spinnerPausingWriter{{w: s.output}, {w: s.errOutput}}
+	color.Output, color.Error = s.pausing[0], s.pausing[1]
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			spinnerMu.Lock()
+			fmt.Fprintf(os.Stderr, "\r%s %s", color.CyanString(spinnerFrames[i%len(spinnerFrames)]), s.message)
+			spinnerShown = true
+			spinnerMu.Unlock()
+			select {
+			case <-s.done:
+				spinnerMu.Lock()
+				clearSpinnerLine()
+				spinnerMu.Unlock()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// stop clears the spinner line and waits for it to finish drawing.
+func (s *spinner) stop() {
+	if s == nil {
+		return
+	}
+	close(s.done)
+	s.wg.Wait()
+	for _, p := range s.pausing {
+		p.flush()
+	}
+	color.Output, color.Error = s.output, s.errOutput
+}
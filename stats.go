@@ -0,0 +1,164 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// statsFile holds the usage counters `uni stats` reports, in uniCacheDir.
+// Nothing is recorded when UNI_NO_STATS is set.
+const statsFile = "stats.json"
+
+// maxTrackedSearches caps how many distinct queries statsFile keeps; the
+// least searched are dropped first.
+const maxTrackedSearches = 100
+
+// usageStats are the counters kept in statsFile.
+type usageStats struct {
+	Searches        map[string]int `json:"searches"`        // Times each query was searched, lowercased
+	Managers        map[string]int `json:"managers"`        // Times detection picked each manager key
+	ExecCacheHits   int            `json:"execCacheHits"`   // uni x runs served from the runner cache
+	ExecCacheMisses int            `json:"execCacheMisses"` // uni x runs that had to detect the runner
+}
+
+func loadStats() usageStats {
+	var stats usageStats
+	readCacheJSON(statsFile, &stats)
+	if stats.Searches == nil {
+		stats.Searches = map[string]int{}
+	}
+	if stats.Managers == nil {
+		stats.Managers = map[string]int{}
+	}
+	return stats
+}
+
+// recordStats applies update to the stored counters. Failing to save them
+// never affects the command being counted.
+func recordStats(update func(stats *usageStats)) {
+	if os.Getenv("UNI_NO_STATS") != "" {
+		return
+	}
+	stats := loadStats()
+	update(&stats)
+	for len(stats.Searches) > maxTrackedSearches {
+		least := slices.MinFunc(slices.Collect(maps.Keys(stats.Searches)), func(a, b string) int {
+			return cmp.Compare(stats.Searches[a], stats.Searches[b])
+		})
+		delete(stats.Searches, least)
+	}
+	data, err := json.Marshal(stats)
+	if err == nil {
+		err = writeCacheFile(statsFile, data)
+	}
+	if err != nil {
+		logVerbose("could not save usage stats: %v", err)
+	}
+}
+
+// statCount is one counter in the `uni stats` report.
+type statCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// topCounts sorts counts from most to least frequent, by name on ties, and
+// keeps the first n.
+func topCounts(counts map[string]int, n int) []statCount {
+	var list []statCount
+	for name, count := range counts {
+		list = append(list, statCount{name, count})
+	}
+	slices.SortFunc(list, func(a, b statCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Name, b.Name))
+	})
+	return list[:min(len(list), n)]
+}
+
+// statsReport is what `uni stats` prints, and its --json shape.
+type statsReport struct {
+	CacheDir        string      `json:"cacheDir"`
+	CacheFiles      int         `json:"cacheFiles"`
+	CacheBytes      int64       `json:"cacheBytes"`
+	ExecCacheSize   int         `json:"execCacheEntries"`
+	ExecCacheHits   int         `json:"execCacheHits"`
+	ExecCacheMisses int         `json:"execCacheMisses"`
+	TopSearches     []statCount `json:"topSearches"`
+	Managers        []statCount `json:"managers"`
+}
+
+// handleStats implements `uni stats [--json]`.
+func handleStats(asJSON bool) {
+	dir, err := uniCacheDir()
+	if err != nil {
+		exitWithError(err)
+	}
+	stats := loadStats()
+	report := statsReport{
+		CacheDir:        dir,
+		ExecCacheSize:   len(loadExecCache()),
+		ExecCacheHits:   stats.ExecCacheHits,
+		ExecCacheMisses: stats.ExecCacheMisses,
+		TopSearches:     topCounts(stats.Searches, 10),
+		Managers:        topCounts(stats.Managers, len(stats.Managers)),
+	}
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			report.CacheFiles++
+			report.CacheBytes += info.Size()
+		}
+		return nil
+	})
+
+	if asJSON {
+		if report.TopSearches == nil {
+			report.TopSearches = []statCount{}
+		}
+		if report.Managers == nil {
+			report.Managers = []statCount{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	fmt.Println(color.CyanString("Cache"))
+	fmt.Printf("  %s: %d files, %.1f KiB\n", report.CacheDir, report.CacheFiles, float64(report.CacheBytes)/1024)
+	fmt.Printf("  Runner cache: %d entries", report.ExecCacheSize)
+	if lookups := report.ExecCacheHits + report.ExecCacheMisses; lookups > 0 {
+		fmt.Printf(", %d%% hit rate over %d runs", report.ExecCacheHits*100/lookups, lookups)
+	}
+	fmt.Println()
+	fmt.Println(color.CyanString("Most searched"))
+	if len(report.TopSearches) == 0 {
+		fmt.Println("  No searches recorded yet.")
+	}
+	for _, search := range report.TopSearches {
+		fmt.Printf("  %4d  %s\n", search.Count, search.Name)
+	}
+	fmt.Println(color.CyanString("Detected managers"))
+	if len(report.Managers) == 0 {
+		fmt.Println("  No detections recorded yet.")
+	}
+	for _, manager := range report.Managers {
+		fmt.Printf("  %4d  %s\n", manager.Count, manager.Name)
+	}
+	if os.Getenv("UNI_NO_STATS") != "" {
+		color.Yellow("UNI_NO_STATS is set, so new usage isn't being recorded.")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/fatih/color"
+)
+
+// venvDir is the Python virtual environment chosen with --venv, relative to
+// the working directory unless absolute. When empty, pip and uv use the
+// environment named by VIRTUAL_ENV, if one is active.
+var venvDir string
+
+// venvBinDir returns the directory holding a virtual environment's
+// executables.
+func venvBinDir(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts")
+	}
+	return filepath.Join(venv, "bin")
+}
+
+// applyVenv points pip and uv at the --venv environment, or the active one,
+// by setting VIRTUAL_ENV and putting its executables first on the child's
+// PATH, in the returned env pairs for the manager's command. uv's pip
+// commands follow VIRTUAL_ENV, and project commands such as `uv add` follow
+// UV_PROJECT_ENVIRONMENT. uni finds executables on its own PATH, so pip is
+// run from the environment directly. Installing with pip outside any
+// environment only gets a warning.
+func applyVenv(pm PackageManagerInfo, args []string) (PackageManagerInfo, []string, error) {
+	if pm.Name != "Pip" && pm.Name != "uv" {
+		if venvDir != "" {
+			color.Yellow("--venv only applies to pip and uv, ignoring it for %s.", pm.Name)
+		}
+		return pm, nil, nil
+	}
+	venv := venvDir
+	if venv == "" {
+		venv = os.Getenv("VIRTUAL_ENV")
+		if venv == "" {
+			if pm.Name == "Pip" && len(args) > 0 && slices.Contains([]string{"install", "i", "add"}, args[0]) && !slices.Contains(args, "--user") {
+				color.Yellow("Warning: no virtual environment is active, so pip installs globally. Pass --venv=<path> to install into one.")
+			}
+			return pm, nil, nil
+		}
+		logVerbose("using the active virtual environment %s", venv)
+	} else if !filepath.IsAbs(venv) {
+		venv = projectPath(venv)
+	}
+	if abs, err := filepath.Abs(venv); err == nil {
+		venv = abs
+	}
+	if _, err := os.Stat(filepath.Join(venv, "pyvenv.cfg")); err != nil {
+		err := newError(ErrUsage, "'%s' is not a virtual environment (it has no pyvenv.cfg)", venv)
+		err.Hint = "Create one with 'python -m venv " + venv + "'."
+		return pm, nil, err
+	}
+
+	bin := venvBinDir(venv)
+	env := []string{"VIRTUAL_ENV=" + venv, "PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH")}
+	if pm.Name == "uv" {
+		return pm, append(env, "UV_PROJECT_ENVIRONMENT="+venv), nil
+	}
+	pip := filepath.Join(bin, "pip")
+	if runtime.GOOS == "windows" {
+		pip += ".exe"
+	}
+	if _, err := os.Stat(pip); err != nil {
+		err := newError(ErrManagerNotInstalled, "the virtual environment %s has no pip", venv)
+		err.Hint = "Run: " + filepath.Join(bin, "python") + " -m ensurepip"
+		return pm, nil, err
+	}
+	pm.Executable = pip
+	return pm, env, nil
+}